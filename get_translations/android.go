@@ -3,14 +3,19 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
 	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
 )
 
 const (
@@ -45,16 +50,32 @@ var (
 		"rmn-Cyrl": "b+rmn+Cyrl",
 		"he":       "iw", // another cool legacy java thing
 	}
-
-	androidResourceRegex = regexp.MustCompile("values-([a-z]{2,})-?r?([A-Za-z]{2,})?")
 )
 
-func updateAndroidAssets(apiKey, baseDir, tag string) error {
+type androidOptions struct {
+	// create values-* directories that don't exist yet instead of skipping the locale
+	CreateDirs bool
+	// write xml/locales_config.xml listing every locale written
+	LocalesConfig bool
+	// don't write anything when validation finds issues
+	Strict bool
+	// split the strings into these modules instead of writing everything to one res directory
+	Modules []AndroidModule
+	// locales less than this percent translated aren't written or listed in locales_config.xml. 0 disables.
+	MinCompletion float64
+	// pseudo-locales to generate from the default resources
+	Pseudo []string
+}
+
+func updateAndroidAssets(apiKey, baseDir, tag string, opts androidOptions) error {
 	// verify that baseDir is valid
 	if !isValidDir(baseDir) {
 		return fmt.Errorf("invalid base dir: %s", baseDir)
 	}
-
+	err := validatePseudoLocales(opts.Pseudo)
+	if err != nil {
+		return err
+	}
 	qp := url.Values{}
 	qp.Add("format", locoAndroidFormat)
 	qp.Add("fallback", locoFallback)
@@ -62,11 +83,19 @@ func updateAndroidAssets(apiKey, baseDir, tag string) error {
 	if tag != "" {
 		qp.Add(locoFilter, tag)
 	}
-	resp, err := locoRequest(apiKey, androidURL, qp)
+	resp, err := lastExports.fetch(apiKey, androidURL, qp, baseDir, opts)
+	if errors.Is(err, errNotModified) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	gate, err := newCompletionGate(apiKey, tag, opts.MinCompletion)
+	if err != nil {
+		return err
+	}
+	defer gate.report()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -80,6 +109,7 @@ func updateAndroidAssets(apiKey, baseDir, tag string) error {
 		return nil
 	}
 
+	files := make([]androidFile, 0, len(zipReader.File))
 	for _, zipFile := range zipReader.File {
 		dir, zipName := filepath.Split(zipFile.Name)
 		ext := filepath.Ext(zipName)
@@ -87,40 +117,6 @@ func updateAndroidAssets(apiKey, baseDir, tag string) error {
 			continue
 		}
 
-		slog.Info("dir", slog.String("dir", dir))
-
-		outputDir := filepath.Join(baseDir, filepath.Base(dir))
-		if !isValidDir(outputDir) {
-			// output directory doesn't exist. we might need to map it
-			matches := androidResourceRegex.FindStringSubmatch(filepath.Base(dir))
-			matches = slices.DeleteFunc(matches, func(s string) bool { return s == "" })
-			if len(matches) < 2 {
-				slog.Error("cannot find matching resource for dir", slog.String("filename", zipFile.Name))
-				continue
-			}
-			var locale, newOutputPath string
-			if len(matches) == 3 {
-				locale = fmt.Sprintf("%s-%s", matches[1], matches[2])
-				newOutputPath = fmt.Sprintf("values-%s-r%s", matches[1], matches[2])
-			} else if len(matches) == 2 {
-				locale = matches[1]
-				newOutputPath = fmt.Sprintf("values-%s", matches[1])
-			}
-
-			if mappedLocale, ok := androidLocaleMap[locale]; ok {
-				newOutputPath = fmt.Sprintf("values-%s", mappedLocale)
-			}
-
-			outputDir = filepath.Join(baseDir, newOutputPath)
-			if !isValidDir(outputDir) {
-				slog.Error("cannot find matching resource for dir after mapping",
-					slog.String("filename", zipFile.Name),
-					slog.String("outputDir", outputDir),
-					slog.String("locale", locale))
-				continue
-			}
-		}
-
 		f, zipErr := zipFile.Open()
 		if zipErr != nil {
 			slog.Error("error opening file",
@@ -129,10 +125,78 @@ func updateAndroidAssets(apiKey, baseDir, tag string) error {
 		}
 
 		xmlData, xmlErr := io.ReadAll(f)
+		f.Close()
 		if xmlErr != nil {
 			slog.Error("error reading zip data for file",
 				slog.String("file", zipFile.Name), slog.Any("err", xmlErr))
-			f.Close()
+			continue
+		}
+		dirName := filepath.Base(dir)
+		if !gate.allows(androidDirLocale(dirName)) {
+			continue
+		}
+		files = append(files, androidFile{Name: zipFile.Name, Dir: dirName, Locale: androidDirLocale(dirName),
+			Data: xmlData})
+	}
+
+	// check everything before writing anything, so a strict run doesn't leave a partial update behind
+	issues := validateAndroidFiles(files)
+	for _, issue := range issues {
+		slog.Warn("android resource issue", slog.String("file", issue.File), slog.String("key", issue.Key),
+			slog.String("issue", issue.Message))
+	}
+	if opts.Strict && len(issues) > 0 {
+		return fmt.Errorf("%w: %d android resource issues, not writing any files", errValidation, len(issues))
+	}
+	// added after the checks: they are copies of the default resources, which don't have e.g. arabic's plural forms
+	files = append(files, pseudoAndroidFiles(files, opts.Pseudo)...)
+
+	if len(opts.Modules) > 0 {
+		return updateAndroidModules(apiKey, baseDir, tag, files, opts.Modules, opts)
+	}
+
+	writtenLocales := writeAndroidFiles(baseDir, files, opts.CreateDirs)
+	if opts.LocalesConfig {
+		return writeLocalesConfig(apiKey, baseDir, writtenLocales)
+	}
+	return nil
+}
+
+// pseudoAndroidFiles generates the pseudo-locales from the default resources, e.g. values-en-rXA
+func pseudoAndroidFiles(files []androidFile, pseudo []string) []androidFile {
+	pseudoFiles := make([]androidFile, 0, len(pseudo))
+	for _, file := range files {
+		if file.Locale != "" {
+			continue
+		}
+		for _, locale := range pseudo {
+			dir := androidResourceDir(locale)
+			pseudoFiles = append(pseudoFiles, androidFile{
+				Name:   path.Join(path.Dir(path.Dir(file.Name)), dir, path.Base(file.Name)),
+				Dir:    dir,
+				Locale: locale,
+				Data:   pseudolocalizeAndroid(locale, file.Data),
+				Create: true,
+			})
+		}
+	}
+	return pseudoFiles
+}
+
+// writeAndroidFiles writes each file to the strings.xml of its resource directory under baseDir and returns the
+// locales that were written
+func writeAndroidFiles(baseDir string, files []androidFile, createDirs bool) []string {
+	writtenLocales := make([]string, 0, len(files))
+	for _, file := range files {
+		slog.Info("dir", slog.String("dir", file.Dir))
+
+		outputDir, locale, dirErr := androidOutputDir(baseDir, file.Dir, createDirs || file.Create)
+		if dirErr != nil {
+			slog.Error("cannot find matching resource for dir",
+				slog.String("filename", file.Name),
+				slog.String("locale", locale),
+				slog.Any("err", dirErr))
+			run.skipped(filepath.Join(baseDir, file.Dir, "strings.xml"), dirErr.Error())
 			continue
 		}
 
@@ -141,16 +205,142 @@ func updateAndroidAssets(apiKey, baseDir, tag string) error {
 		if fileErr != nil {
 			slog.Error("error creating file",
 				slog.String("file", outFilePath), slog.Any("err", fileErr))
+			run.failed(outFilePath, fileErr)
+			continue
+		}
+		_, fileErr = outFile.Write(file.Data)
+		if run.record(outFilePath, fileErr) != nil {
+			slog.Error("error writing to file",
+				slog.String("file", outFilePath), slog.Any("err", fileErr))
 		} else {
-			_, fileErr = outFile.Write(xmlData)
-			if fileErr != nil {
-				slog.Error("error writing to file",
-					slog.String("file", outFilePath), slog.Any("err", fileErr))
-			}
-			outFile.Close()
+			writtenLocales = append(writtenLocales, locale)
 		}
-		f.Close()
+		outFile.Close()
 	}
+	return writtenLocales
+}
 
-	return nil
+// androidOutputDir finds the resource directory for a values-* directory from the loco archive. the directory loco
+// uses is preferred, then the one android expects for the locale. locale is empty for the default resources.
+func androidOutputDir(baseDir, dirName string, create bool) (outputDir, locale string, err error) {
+	locale = androidDirLocale(dirName)
+	candidates := []string{dirName}
+	if locale != "" {
+		candidates = append(candidates, androidResourceDir(locale))
+	}
+	for _, candidate := range candidates {
+		outputDir = filepath.Join(baseDir, candidate)
+		if isValidDir(outputDir) {
+			return outputDir, locale, nil
+		}
+	}
+
+	if !create {
+		return "", locale, fmt.Errorf("no resource directory: tried %s", strings.Join(candidates, ", "))
+	}
+	slog.Info("creating resource directory", slog.String("dir", outputDir), slog.String("locale", locale))
+	return outputDir, locale, os.MkdirAll(outputDir, os.ModePerm)
+}
+
+// androidDirLocale turns a resource directory name into a BCP 47 locale, e.g. values-pt-rBR into pt-BR and
+// values-b+sr+Latn into sr-Latn
+func androidDirLocale(dirName string) string {
+	qualifier := strings.TrimPrefix(strings.TrimPrefix(dirName, "values"), "-")
+	if qualifier == "" {
+		return ""
+	}
+	if strings.HasPrefix(qualifier, "b+") {
+		return strings.ReplaceAll(strings.TrimPrefix(qualifier, "b+"), "+", "-")
+	}
+	parts := strings.Split(qualifier, "-")
+	if len(parts) > 1 && strings.HasPrefix(parts[1], "r") {
+		return parts[0] + "-" + strings.TrimPrefix(parts[1], "r")
+	}
+	return parts[0]
+}
+
+// androidResourceDir is the values-* directory android expects for a BCP 47 locale. anything the old two letter
+// language/region qualifier can't express (scripts, variants, three letter languages, numeric regions) uses the b+
+// form.
+func androidResourceDir(locale string) string {
+	if mappedLocale, ok := androidLocaleMap[locale]; ok {
+		return fmt.Sprintf("values-%s", mappedLocale)
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return fmt.Sprintf("values-%s", locale)
+	}
+	base, _ := tag.Base()
+	script, scriptConf := tag.Script()
+	region, regionConf := tag.Region()
+	variants := tag.Variants()
+	hasScript := scriptConf == language.Exact
+	hasRegion := regionConf == language.Exact
+	// the -r qualifier takes any two letter region, including the private use ones of the pseudo-locales (en-XA,
+	// ar-XB) that x/text doesn't count as countries; numeric regions like 419 need the b+ form
+	letterRegion := len(region.String()) == 2
+
+	if hasScript || len(base.String()) > 2 || (hasRegion && !letterRegion) || len(variants) > 0 {
+		parts := []string{"b", base.String()}
+		if hasScript {
+			parts = append(parts, script.String())
+		}
+		if hasRegion {
+			parts = append(parts, region.String())
+		}
+		for _, variant := range variants {
+			parts = append(parts, variant.String())
+		}
+		return "values-" + strings.Join(parts, "+")
+	}
+	if hasRegion {
+		return fmt.Sprintf("values-%s-r%s", base, region)
+	}
+	return fmt.Sprintf("values-%s", base)
+}
+
+// writeLocalesConfig generates xml/locales_config.xml for android 13 per-app language support. the source locale is
+// always listed, since it's what the default values directory contains.
+func writeLocalesConfig(apiKey, baseDir string, writtenLocales []string) error {
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco project")
+	}
+
+	configDir := filepath.Join(baseDir, "xml")
+	err = os.MkdirAll(configDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(configDir, "locales_config.xml")
+	return run.record(path, os.WriteFile(path, localesConfigXML(source.Code, writtenLocales), 0666))
+}
+
+func localesConfigXML(sourceCode string, writtenLocales []string) []byte {
+	source := language.Make(sourceCode).String()
+	tags := make([]string, 0, len(writtenLocales))
+	for _, locale := range writtenLocales {
+		if locale == "" {
+			continue
+		}
+		tag := language.Make(locale).String()
+		if tag != source && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n")
+	buf.WriteString("<locale-config xmlns:android=\"http://schemas.android.com/apk/res/android\">\n")
+	for _, tag := range append([]string{source}, tags...) {
+		buf.WriteString(fmt.Sprintf("    <locale android:name=\"%s\" />\n", tag))
+	}
+	buf.WriteString("</locale-config>\n")
+	return buf.Bytes()
 }
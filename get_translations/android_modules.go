@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var androidInvalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// androidModuleRouter decides which modules a string resource belongs to
+type androidModuleRouter struct {
+	modules []AndroidModule
+	// resource name -> loco tags
+	tags map[string][]string
+	// resource name -> asset ID
+	ids map[string]string
+}
+
+func newAndroidModuleRouter(modules []AndroidModule, assets []LocoAsset) *androidModuleRouter {
+	router := &androidModuleRouter{
+		modules: modules,
+		tags:    make(map[string][]string, len(assets)),
+		ids:     make(map[string]string, len(assets)),
+	}
+	for _, asset := range assets {
+		for _, name := range []string{asset.ID, androidResourceName(asset.ID)} {
+			router.tags[name] = asset.Tags
+			router.ids[name] = asset.ID
+		}
+	}
+	return router
+}
+
+// androidResourceName is the name loco gives an asset in strings.xml. resource names can only be java identifiers.
+func androidResourceName(assetID string) string {
+	return androidInvalidNameChars.ReplaceAllString(assetID, "_")
+}
+
+// modulesFor returns the indexes of the modules that claim the resource, by tag or by asset ID prefix
+func (r *androidModuleRouter) modulesFor(resourceName string) []int {
+	assetID, ok := r.ids[resourceName]
+	if !ok {
+		assetID = resourceName
+	}
+	claimed := make([]int, 0, 1)
+	for i, module := range r.modules {
+		matched := false
+		for _, tag := range r.tags[resourceName] {
+			if slices.Contains(module.Tags, tag) {
+				matched = true
+				break
+			}
+		}
+		for _, prefix := range module.Prefixes {
+			if matched {
+				break
+			}
+			matched = strings.HasPrefix(assetID, prefix) || strings.HasPrefix(resourceName, prefix)
+		}
+		if matched {
+			claimed = append(claimed, i)
+		}
+	}
+	return claimed
+}
+
+// splitAndroidFiles routes every resource in the files to the modules that claim it. the result has one set of files
+// per module, in the same order as the modules. resources claimed by no module or by more than one are logged once.
+func splitAndroidFiles(files []androidFile, router *androidModuleRouter) ([][]androidFile, error) {
+	moduleFiles := make([][]androidFile, len(router.modules))
+	warned := make(map[string]bool)
+	for _, file := range files {
+		var resources androidResources
+		err := xml.Unmarshal(file.Data, &resources)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+
+		moduleItems := make([][]androidResource, len(router.modules))
+		for _, res := range resources.Items {
+			claimed := router.modulesFor(res.Name)
+			if !warned[res.Name] {
+				if len(claimed) == 0 {
+					slog.Warn("string not claimed by any module", slog.String("name", res.Name))
+					warned[res.Name] = true
+				} else if len(claimed) > 1 {
+					names := make([]string, len(claimed))
+					for i, m := range claimed {
+						names[i] = router.modules[m].Name
+					}
+					slog.Warn("string claimed by more than one module", slog.String("name", res.Name),
+						slog.String("modules", strings.Join(names, ", ")))
+					warned[res.Name] = true
+				}
+			}
+			for _, m := range claimed {
+				moduleItems[m] = append(moduleItems[m], res)
+			}
+		}
+
+		for m, items := range moduleItems {
+			if len(items) == 0 {
+				continue
+			}
+			moduleFile := file
+			moduleFile.Data = encodeAndroidResources(resources.Attrs, items)
+			moduleFiles[m] = append(moduleFiles[m], moduleFile)
+		}
+	}
+	return moduleFiles, nil
+}
+
+func updateAndroidModules(apiKey, baseDir, tag string, files []androidFile, modules []AndroidModule,
+	opts androidOptions) error {
+	assets, err := getAssets(apiKey, tag)
+	if err != nil {
+		return err
+	}
+	moduleFiles, err := splitAndroidFiles(files, newAndroidModuleRouter(modules, assets))
+	if err != nil {
+		return err
+	}
+	return writeAndroidModules(apiKey, baseDir, modules, moduleFiles, opts)
+}
+
+// writeAndroidModules writes each module's files into its res directory. a module whose res directory doesn't exist
+// is recorded as failed, and the other modules are still written.
+func writeAndroidModules(apiKey, baseDir string, modules []AndroidModule, moduleFiles [][]androidFile,
+	opts androidOptions) error {
+	for m, module := range modules {
+		resDir := filepath.Join(baseDir, module.ResDir)
+		if !isValidDir(resDir) {
+			slog.Error("invalid module res dir", slog.String("module", module.Name), slog.String("dir", resDir))
+			run.failed(resDir, fmt.Errorf("module %s: invalid res dir %s", module.Name, resDir))
+			continue
+		}
+		writtenLocales := writeAndroidFiles(resDir, moduleFiles[m], opts.CreateDirs)
+		slog.Info("wrote module strings", slog.String("module", module.Name),
+			slog.Int("locales", len(writtenLocales)))
+		if opts.LocalesConfig && module.LocalesConfig {
+			err := writeLocalesConfig(apiKey, resDir, writtenLocales)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeAndroidResources writes a strings.xml containing items. the items keep their original attributes and
+// content.
+func encodeAndroidResources(rootAttrs []xml.Attr, items []androidResource) []byte {
+	// encoding/xml replaces attribute prefixes with the namespace URL, so map them back
+	prefixes := make(map[string]string)
+	for _, attr := range rootAttrs {
+		if attr.Name.Space == "xmlns" {
+			prefixes[attr.Value] = attr.Name.Local
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<resources")
+	writeAndroidAttrs(&buf, rootAttrs, prefixes)
+	buf.WriteString(">\n")
+	for _, item := range items {
+		buf.WriteString("    <" + item.XMLName.Local)
+		itemAttrs := make([]xml.Attr, 0, len(item.Attrs)+2)
+		itemAttrs = append(itemAttrs, xml.Attr{Name: xml.Name{Local: "name"}, Value: item.Name})
+		if item.Formatted != "" {
+			itemAttrs = append(itemAttrs, xml.Attr{Name: xml.Name{Local: "formatted"}, Value: item.Formatted})
+		}
+		writeAndroidAttrs(&buf, append(itemAttrs, item.Attrs...), prefixes)
+		buf.WriteString(">" + item.Inner + "</" + item.XMLName.Local + ">\n")
+	}
+	buf.WriteString("</resources>\n")
+	return buf.Bytes()
+}
+
+func writeAndroidAttrs(buf *bytes.Buffer, attrs []xml.Attr, prefixes map[string]string) {
+	for _, attr := range attrs {
+		name := attr.Name.Local
+		if attr.Name.Space == "xmlns" {
+			name = "xmlns:" + name
+		} else if prefix, ok := prefixes[attr.Name.Space]; ok {
+			name = prefix + ":" + name
+		} else if attr.Name.Space != "" {
+			name = attr.Name.Space + ":" + name
+		}
+		buf.WriteString(" " + name + `="`)
+		_ = xml.EscapeText(buf, []byte(attr.Value))
+		buf.WriteString(`"`)
+	}
+}
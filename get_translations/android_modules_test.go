@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitAndroidFiles(t *testing.T) {
+	source := `<?xml version="1.0" encoding="utf-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+    <string name="schedules_title">Schedules &amp; more</string>
+    <string name="reports_title" tools:ignore="MissingTranslation">Reports</string>
+    <plurals name="reports_count">
+        <item quantity="one">%d report</item>
+        <item quantity="other">%d reports</item>
+    </plurals>
+    <string name="shared_ok">OK</string>
+    <string name="orphan">Nobody wants me</string>
+</resources>`
+
+	modules := []AndroidModule{
+		{Name: "schedules", ResDir: "feature-schedules/src/main/res", Prefixes: []string{"schedules."}},
+		{Name: "reports", ResDir: "feature-reports/src/main/res", Tags: []string{"reports"}},
+		{Name: "common", ResDir: "app/src/main/res", Tags: []string{"shared"}, Prefixes: []string{"reports.title"}},
+	}
+	assets := []LocoAsset{
+		{ID: "schedules.title"},
+		{ID: "reports.title", Tags: []string{"mobile-apps", "reports"}},
+		{ID: "reports.count", Tags: []string{"mobile-apps", "reports"}},
+		{ID: "shared.ok", Tags: []string{"shared"}},
+		{ID: "orphan"},
+	}
+
+	moduleFiles, err := splitAndroidFiles([]androidFile{{Name: "res/values/strings.xml", Dir: "values",
+		Data: []byte(source)}}, newAndroidModuleRouter(modules, assets))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+    <string name="schedules_title">Schedules &amp; more</string>
+</resources>
+`,
+		`<?xml version="1.0" encoding="UTF-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+    <string name="reports_title" tools:ignore="MissingTranslation">Reports</string>
+    <plurals name="reports_count">
+        <item quantity="one">%d report</item>
+        <item quantity="other">%d reports</item>
+    </plurals>
+</resources>
+`,
+		`<?xml version="1.0" encoding="UTF-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+    <string name="reports_title" tools:ignore="MissingTranslation">Reports</string>
+    <string name="shared_ok">OK</string>
+</resources>
+`,
+	}
+	if len(moduleFiles) != len(expected) {
+		t.Fatalf("got %d modules, want %d", len(moduleFiles), len(expected))
+	}
+	for i, files := range moduleFiles {
+		if len(files) != 1 {
+			t.Errorf("module %s has %d files, want 1", modules[i].Name, len(files))
+			continue
+		}
+		if string(files[0].Data) != expected[i] {
+			t.Errorf("module %s got\n%s\nwant\n%s", modules[i].Name, files[0].Data, expected[i])
+		}
+	}
+}
+
+func TestModulesFor(t *testing.T) {
+	modules := []AndroidModule{
+		{Name: "a", Prefixes: []string{"a."}},
+		{Name: "b", Tags: []string{"b"}},
+	}
+	router := newAndroidModuleRouter(modules, []LocoAsset{{ID: "a.one", Tags: []string{"b"}}, {ID: "c"}})
+	tests := []struct {
+		name     string
+		expected []int
+	}{
+		{name: "a_one", expected: []int{0, 1}},
+		{name: "c", expected: []int{}},
+		{name: "a.not_in_loco", expected: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := router.modulesFor(tt.name)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("modulesFor(%q) = %v, want %v", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteAndroidModulesInvalidResDir(t *testing.T) {
+	defer run.reset()
+	run.reset()
+	baseDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(baseDir, "app", "src", "main", "res", "values"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	modules := []AndroidModule{
+		{Name: "app", ResDir: "app/src/main/res"},
+		{Name: "typo", ResDir: "feature-x/src/main/rse"},
+	}
+	file := androidFile{Name: "res/values/strings.xml", Dir: "values", Data: []byte("<resources/>\n")}
+	err := writeAndroidModules("", baseDir, modules, [][]androidFile{{file}, {file}}, androidOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := run.finish("get_translations android", nil); code != exitPartial {
+		t.Errorf("finish() = %d, want %d for a module without its res dir", code, exitPartial)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestAndroidDirLocale(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "values", expected: ""},
+		{input: "values-fr", expected: "fr"},
+		{input: "values-pt-rBR", expected: "pt-BR"},
+		{input: "values-b+sr+Latn", expected: "sr-Latn"},
+		{input: "values-b+es+419", expected: "es-419"},
+		{input: "values-en-rXA", expected: "en-XA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := androidDirLocale(tt.input)
+			if result != tt.expected {
+				t.Errorf("androidDirLocale(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAndroidResourceDir(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "fr", expected: "values-fr"},
+		{input: "pt-BR", expected: "values-pt-rBR"},
+		{input: "es-MX", expected: "values-es-rMX"},
+		{input: "pl-PL", expected: "values-pl"},
+		{input: "id-ID", expected: "values-in"},
+		{input: "zh-Hant", expected: "values-b+zh+Hant"},
+		{input: "sr-Latn", expected: "values-b+sr+Latn"},
+		{input: "es-419", expected: "values-b+es+419"},
+		{input: "kea", expected: "values-b+kea"},
+		{input: "ca-valencia", expected: "values-b+ca+valencia"},
+		{input: "en-XA", expected: "values-en-rXA"},
+		{input: "ar-XB", expected: "values-ar-rXB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := androidResourceDir(tt.input)
+			if result != tt.expected {
+				t.Errorf("androidResourceDir(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPseudoAndroidFiles(t *testing.T) {
+	files := []androidFile{
+		{Name: "hourglass-android/res/values/strings.xml", Dir: "values",
+			Data: []byte(`<resources><string name="hello">Hello</string></resources>`)},
+		{Name: "hourglass-android/res/values-de/strings.xml", Dir: "values-de", Locale: "de"},
+	}
+	got := pseudoAndroidFiles(files, []string{"en-XA", "ar-XB"})
+	want := []string{"hourglass-android/res/values-en-rXA/strings.xml", "hourglass-android/res/values-ar-rXB/strings.xml"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d pseudo files, want %d", len(got), len(want))
+	}
+	for i, file := range got {
+		if file.Name != want[i] || file.Dir != path.Base(path.Dir(want[i])) {
+			t.Errorf("pseudo file %d = %s in %s, want %s", i, file.Name, file.Dir, want[i])
+		}
+	}
+}
+
+func TestLocalesConfigXML(t *testing.T) {
+	result := string(localesConfigXML("en-US", []string{"", "pt-BR", "fr", "en-US", "fr"}))
+	expected := `<?xml version="1.0" encoding="utf-8"?>
+<locale-config xmlns:android="http://schemas.android.com/apk/res/android">
+    <locale android:name="en-US" />
+    <locale android:name="fr" />
+    <locale android:name="pt-BR" />
+</locale-config>
+`
+	if strings.TrimSpace(result) != strings.TrimSpace(expected) {
+		t.Errorf("localesConfigXML =\n%s\nwant\n%s", result, expected)
+	}
+}
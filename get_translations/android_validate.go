@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+type androidResources struct {
+	XMLName xml.Name          `xml:"resources"`
+	Attrs   []xml.Attr        `xml:",any,attr"`
+	Items   []androidResource `xml:",any"`
+}
+
+type androidResource struct {
+	XMLName   xml.Name
+	Name      string        `xml:"name,attr"`
+	Formatted string        `xml:"formatted,attr"`
+	Attrs     []xml.Attr    `xml:",any,attr"`
+	Text      string        `xml:",chardata"`
+	Items     []androidItem `xml:"item"`
+	Inner     string        `xml:",innerxml"`
+}
+
+type androidItem struct {
+	Quantity string `xml:"quantity,attr"`
+	Text     string `xml:",chardata"`
+}
+
+// androidFile is a strings.xml from the loco archive
+type androidFile struct {
+	Name   string // path in the archive
+	Dir    string // values-* directory name
+	Locale string // empty for the default resources
+	Data   []byte
+	// create the resource directory even without --create-dirs, e.g. for pseudo-locales
+	Create bool
+}
+
+type androidIssue struct {
+	File    string
+	Key     string
+	Message string
+}
+
+var (
+	androidFormatRegex = regexp.MustCompile(`%(\d+\$)?[-#+ 0,(]*\d*(\.\d+)?([a-zA-Z%])`)
+	androidFormatTypes = "bBhHsScCdoxXeEfgGaAtT"
+	pluralFormNames    = map[plural.Form]string{
+		plural.Zero:  "zero",
+		plural.One:   "one",
+		plural.Two:   "two",
+		plural.Few:   "few",
+		plural.Many:  "many",
+		plural.Other: "other",
+	}
+)
+
+// validateAndroidFiles checks every string and plural in the files for the problems aapt2 fails on. the default
+// resources are used as the reference for format arguments.
+func validateAndroidFiles(files []androidFile) []androidIssue {
+	issues := make([]androidIssue, 0)
+	parsed := make([]androidResources, len(files))
+	var sourceArgs map[string]map[int]string
+	for i, file := range files {
+		err := xml.Unmarshal(file.Data, &parsed[i])
+		if err != nil {
+			issues = append(issues, androidIssue{File: file.Name, Message: fmt.Sprintf("invalid xml: %v", err)})
+			continue
+		}
+		if file.Locale == "" {
+			sourceArgs = resourceFormatArgs(parsed[i])
+		}
+	}
+
+	for i, file := range files {
+		for _, res := range parsed[i].Items {
+			for _, msg := range validateAndroidResource(res, language.Make(file.Locale), sourceArgs[res.Name]) {
+				issues = append(issues, androidIssue{File: file.Name, Key: res.Name, Message: msg})
+			}
+		}
+	}
+	return issues
+}
+
+func validateAndroidResource(res androidResource, lang language.Tag, sourceArgs map[int]string) []string {
+	messages := make([]string, 0)
+	switch res.XMLName.Local {
+	case "string":
+		messages = append(messages, validateAndroidString(res.Text, res.Formatted == "false", sourceArgs)...)
+	case "plurals":
+		quantities := make(map[string]bool, len(res.Items))
+		for _, item := range res.Items {
+			quantities[item.Quantity] = true
+			for _, msg := range validateAndroidString(item.Text, res.Formatted == "false", sourceArgs) {
+				messages = append(messages, fmt.Sprintf("quantity %s: %s", item.Quantity, msg))
+			}
+		}
+		for _, required := range requiredQuantities(lang) {
+			if !quantities[required] {
+				messages = append(messages, fmt.Sprintf("missing plural quantity %q", required))
+			}
+		}
+	}
+	return messages
+}
+
+func validateAndroidString(text string, formattedFalse bool, sourceArgs map[int]string) []string {
+	messages := make([]string, 0)
+	trimmed := strings.TrimSpace(text)
+	quoted := len(trimmed) > 1 && strings.HasPrefix(trimmed, `"`) && strings.HasSuffix(trimmed, `"`)
+
+	if !quoted && hasUnescaped(text, '\'') {
+		messages = append(messages, `unescaped apostrophe, use \'`)
+	}
+	if strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, "?") {
+		messages = append(messages, fmt.Sprintf(`starts with %q, escape it with \%s`, trimmed[:1], trimmed[:1]))
+	}
+	if formattedFalse {
+		return messages
+	}
+
+	args, nonPositional, invalid := formatArgs(text)
+	if invalid != "" {
+		messages = append(messages, fmt.Sprintf(`invalid format %q, escape it as %%%% or add formatted="false"`, invalid))
+	}
+	if nonPositional > 1 {
+		messages = append(messages, `multiple non-positional arguments, use %1$s style or add formatted="false"`)
+	}
+	if sourceArgs != nil {
+		for index, verb := range args {
+			sourceVerb, ok := sourceArgs[index]
+			if !ok {
+				messages = append(messages, fmt.Sprintf("argument %d is not in the source string", index))
+			} else if sourceVerb != verb {
+				messages = append(messages, fmt.Sprintf("argument %d is %%%s, source has %%%s", index, verb, sourceVerb))
+			}
+		}
+	}
+	return messages
+}
+
+// formatArgs finds the java format arguments in text. positional and non-positional arguments are numbered the way
+// java.util.Formatter does. invalid is the first thing that looks like a format argument but isn't one.
+func formatArgs(text string) (args map[int]string, nonPositional int, invalid string) {
+	args = make(map[int]string)
+	for _, match := range androidFormatRegex.FindAllStringSubmatch(text, -1) {
+		verb := match[3]
+		if verb == "%" || verb == "n" {
+			continue
+		}
+		if !strings.Contains(androidFormatTypes, verb) {
+			if invalid == "" {
+				invalid = match[0]
+			}
+			continue
+		}
+		if match[1] != "" {
+			index, _ := strconv.Atoi(strings.TrimSuffix(match[1], "$"))
+			args[index] = verb
+		} else {
+			nonPositional++
+			args[nonPositional] = verb
+		}
+	}
+	// a % that isn't followed by anything that could be a format at all
+	if invalid == "" {
+		stripped := androidFormatRegex.ReplaceAllString(text, "")
+		if idx := strings.Index(stripped, "%"); idx >= 0 {
+			invalid = stripped[idx:]
+			if len(invalid) > 2 {
+				invalid = invalid[:2]
+			}
+		}
+	}
+	return
+}
+
+func resourceFormatArgs(resources androidResources) map[string]map[int]string {
+	argsByName := make(map[string]map[int]string, len(resources.Items))
+	for _, res := range resources.Items {
+		if res.Formatted == "false" {
+			continue
+		}
+		switch res.XMLName.Local {
+		case "string":
+			argsByName[res.Name], _, _ = formatArgs(res.Text)
+		case "plurals":
+			// the "other" form is the one that has all the arguments
+			for _, item := range res.Items {
+				if item.Quantity == pluralFormNames[plural.Other] {
+					argsByName[res.Name], _, _ = formatArgs(item.Text)
+				}
+			}
+		}
+	}
+	return argsByName
+}
+
+// hasUnescaped reports whether text contains c without a backslash before it
+func hasUnescaped(text string, c rune) bool {
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == c:
+			return true
+		}
+	}
+	return false
+}
+
+// requiredQuantities returns the plural quantities the CLDR rules for lang use for whole numbers. "other" is always
+// required since android falls back to it.
+func requiredQuantities(lang language.Tag) []string {
+	forms := pluralCategories(plural.Cardinal, lang, false)
+	quantities := make([]string, 0, len(forms))
+	for form := range forms {
+		quantities = append(quantities, form)
+	}
+	sort.Strings(quantities)
+	return quantities
+}
+
+func (issue androidIssue) String() string {
+	if issue.Key == "" {
+		return fmt.Sprintf("%s: %s", issue.File, issue.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", issue.File, issue.Key, issue.Message)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestValidateAndroidString(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		formattedFalse bool
+		sourceArgs     map[int]string
+		expected       int
+	}{
+		{name: "Plain", text: "Hello world", expected: 0},
+		{name: "Escaped apostrophe", text: `Don\'t go`, expected: 0},
+		{name: "Unescaped apostrophe", text: "Don't go", expected: 1},
+		{name: "Quoted apostrophe", text: `"Don't go"`, expected: 0},
+		{name: "Leading at", text: "@home", expected: 1},
+		{name: "Escaped leading at", text: `\@home`, expected: 0},
+		{name: "Leading question mark", text: "?really", expected: 1},
+		{name: "Positional args", text: "%1$s has %2$d items", sourceArgs: map[int]string{1: "s", 2: "d"},
+			expected: 0},
+		{name: "Multiple non-positional args", text: "%s has %d items", expected: 1},
+		{name: "Multiple non-positional args formatted false", text: "%s has %d items", formattedFalse: true,
+			expected: 0},
+		{name: "Escaped percent", text: "100%% done", expected: 0},
+		{name: "Bare percent", text: "100%", expected: 1},
+		{name: "Arg not in source", text: "%1$s and %2$s", sourceArgs: map[int]string{1: "s"}, expected: 1},
+		{name: "Arg type mismatch", text: "%1$d items", sourceArgs: map[int]string{1: "s"}, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := validateAndroidString(tt.text, tt.formattedFalse, tt.sourceArgs)
+			if len(messages) != tt.expected {
+				t.Errorf("validateAndroidString(%q) = %v, want %d messages", tt.text, messages, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRequiredQuantities(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected []string
+	}{
+		{locale: "en", expected: []string{"one", "other"}},
+		{locale: "ja", expected: []string{"other"}},
+		{locale: "ru", expected: []string{"few", "many", "one", "other"}},
+		{locale: "ar", expected: []string{"few", "many", "one", "other", "two", "zero"}},
+		// CLDR's many for whole millions, which the lint rules also know about
+		{locale: "fr", expected: []string{"many", "one", "other"}},
+		{locale: "pt-BR", expected: []string{"many", "one", "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			result := requiredQuantities(language.MustParse(tt.locale))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("requiredQuantities(%s) = %v, want %v", tt.locale, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateAndroidFiles(t *testing.T) {
+	source := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="greeting">Hello %1$s</string>
+    <plurals name="items">
+        <item quantity="one">%1$d item</item>
+        <item quantity="other">%1$d items</item>
+    </plurals>
+</resources>`
+	russian := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="greeting">Привет %1$d</string>
+    <plurals name="items">
+        <item quantity="one">%1$d элемент</item>
+        <item quantity="other">%1$d элементов</item>
+    </plurals>
+</resources>`
+
+	issues := validateAndroidFiles([]androidFile{
+		{Name: "res/values/strings.xml", Dir: "values", Data: []byte(source)},
+		{Name: "res/values-ru/strings.xml", Dir: "values-ru", Locale: "ru", Data: []byte(russian)},
+	})
+	expected := []androidIssue{
+		{File: "res/values-ru/strings.xml", Key: "greeting", Message: "argument 1 is %d, source has %s"},
+		{File: "res/values-ru/strings.xml", Key: "items", Message: `missing plural quantity "few"`},
+		{File: "res/values-ru/strings.xml", Key: "items", Message: `missing plural quantity "many"`},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("validateAndroidFiles = %v, want %v", issues, expected)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	arbFilePattern = "app_%s.arb"
+	// the plural argument when no placeholder in the plural forms is a number
+	arbDefaultCount = "count"
+)
+
+type arbPlaceholder struct {
+	Name string
+	Type string
+}
+
+type arbMessage struct {
+	Key          string
+	Value        string
+	Description  string
+	Placeholders []arbPlaceholder
+}
+
+// exportARB writes an app_<locale>.arb file per locale for flutter's gen-l10n. untranslated strings are left out, so
+// flutter falls back to the source locale for them.
+func exportARB(apiKey, dir, tag string, opts exportOptions) error {
+	if !isValidDir(dir) {
+		return fmt.Errorf("invalid directory: %s", dir)
+	}
+	gate, err := newCompletionGate(apiKey, tag, opts.MinCompletion)
+	if err != nil {
+		return err
+	}
+	defer gate.report()
+
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	assets, err := getAssets(apiKey, tag)
+	if err != nil {
+		return err
+	}
+	translations, err := fetchAllTranslations(apiKey, assets)
+	if err != nil {
+		return err
+	}
+
+	for _, loc := range allLocales {
+		if !gate.allows(loc.Code) {
+			continue
+		}
+		messages := arbMessages(assets, translations, loc)
+		arbLocale := arbLocaleCode(loc.Code)
+		path := filepath.Join(dir, fmt.Sprintf(arbFilePattern, arbLocale))
+		err = run.record(path, os.WriteFile(path, encodeARB(arbLocale, messages), 0666))
+		if err != nil {
+			return err
+		}
+		slog.Info("wrote ARB", slog.String("file", path), slog.Int("messages", len(messages)))
+	}
+	return nil
+}
+
+// arbLocaleCode maps a loco locale through the shared locale table into the form flutter uses in file names, e.g.
+// pt_BR
+func arbLocaleCode(code string) string {
+	if mapped := locales[code]; mapped != "" {
+		code = mapped
+	}
+	return strings.ReplaceAll(code, "-", "_")
+}
+
+// arbKey turns an asset ID into a dart identifier, e.g. import.drop-here %(filename)s into importDropHere_Filename
+func arbKey(assetID string) string {
+	identifier := strings.TrimPrefix(validConstant(assetID), "_")
+	r, size := utf8.DecodeRuneInString(identifier)
+	return string(unicode.ToLower(r)) + identifier[size:]
+}
+
+func arbMessages(assets []LocoAsset, translations map[string][]LocoTranslation, loc LocoLocale) []arbMessage {
+	// the plural forms of an asset are assets of their own, which are exported as part of it
+	pluralIDs := make(map[string]bool)
+	for _, assetTranslations := range translations {
+		for _, translation := range assetTranslations {
+			for _, plural := range translation.Plurals {
+				pluralIDs[plural.ID] = true
+			}
+		}
+	}
+
+	messages := make([]arbMessage, 0, len(assets))
+	for _, asset := range assets {
+		if pluralIDs[asset.ID] {
+			continue
+		}
+		for _, translation := range translations[asset.ID] {
+			if translation.Locale.Code != loc.Code || !translation.Translated {
+				continue
+			}
+			msg := arbMessage{Key: arbKey(asset.ID), Description: asset.Notes}
+			if len(translation.Plurals) > 0 {
+				msg.Value, msg.Placeholders = arbPlural(translation, loc.Plurals.Forms)
+			} else {
+				msg.Value, msg.Placeholders = icuPlaceholders(translation.Translation)
+			}
+			messages = append(messages, msg)
+			break
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Key < messages[j].Key })
+	return messages
+}
+
+// icuPlaceholders converts the placeholders in a loco string to flutter's {name} form and declares them. python and
+// i18next placeholders keep their names; printf ones are named by position.
+func icuPlaceholders(s string) (string, []arbPlaceholder) {
+	placeholders := make([]arbPlaceholder, 0)
+	declare := func(name, verb string) string {
+		placeholderType := "String"
+		switch verb {
+		case "d", "i", "u":
+			placeholderType = "int"
+		case "f", "e", "g", "E", "G":
+			placeholderType = "double"
+		}
+		if !containsPlaceholder(placeholders, name) {
+			placeholders = append(placeholders, arbPlaceholder{Name: name, Type: placeholderType})
+		}
+		return "{" + name + "}"
+	}
+
+	plain := 0
+	converted := placeholderRegex.ReplaceAllStringFunc(s, func(p string) string {
+		verb := p[len(p)-1:]
+		switch {
+		case strings.HasPrefix(p, "%("):
+			return declare(p[2:strings.Index(p, ")")], verb)
+		case strings.HasPrefix(p, "{{"):
+			return declare(strings.TrimSpace(strings.Trim(p, "{}")), "")
+		case strings.HasPrefix(p, "{"):
+			name, _, _ := strings.Cut(strings.Trim(p, "{}"), ",")
+			declare(strings.TrimSpace(name), "")
+			return p
+		case p == "%%":
+			return "%"
+		case strings.HasPrefix(p, "%#@"):
+			// stringsdict variables only mean something on iOS
+			return p
+		case strings.Contains(p, "$"):
+			position := p[1:strings.Index(p, "$")]
+			return declare("arg"+position, verb)
+		}
+		plain++
+		return declare("arg"+strconv.Itoa(plain), verb)
+	})
+	return converted, placeholders
+}
+
+// arbPlural builds an ICU plural from a translation and its plural forms. loco keeps the forms in the order of the
+// locale's plural categories, starting with the translation itself.
+func arbPlural(translation LocoTranslation, forms []string) (string, []arbPlaceholder) {
+	texts := []string{translation.Translation}
+	for _, plural := range translation.Plurals {
+		texts = append(texts, plural.Translation)
+	}
+	if len(forms) == 0 {
+		forms = []string{"one", "other"}
+	}
+
+	placeholders := make([]arbPlaceholder, 0)
+	cases := make([]string, 0, len(texts))
+	for i, text := range texts {
+		if i >= len(forms) || text == "" {
+			continue
+		}
+		converted, formPlaceholders := icuPlaceholders(text)
+		for _, p := range formPlaceholders {
+			if !containsPlaceholder(placeholders, p.Name) {
+				placeholders = append(placeholders, p)
+			}
+		}
+		cases = append(cases, fmt.Sprintf("%s{%s}", forms[i], converted))
+	}
+
+	count := ""
+	for _, p := range placeholders {
+		if p.Type == "int" || p.Type == "double" {
+			count = p.Name
+			break
+		}
+	}
+	if count == "" {
+		count = arbDefaultCount
+		placeholders = append(placeholders, arbPlaceholder{Name: count, Type: "num"})
+	}
+	return fmt.Sprintf("{%s, plural, %s}", count, strings.Join(cases, " ")), placeholders
+}
+
+func containsPlaceholder(placeholders []arbPlaceholder, name string) bool {
+	for _, p := range placeholders {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeARB writes the messages with each one's @key metadata right after it, which encoding/json's sorted maps
+// can't do
+func encodeARB(locale string, messages []arbMessage) []byte {
+	var buf bytes.Buffer
+	marshal := func(value any) string {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		// the messages are often html
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("  ", "  ")
+		_ = enc.Encode(value)
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	writeEntry := func(key string, value any, last bool) {
+		buf.WriteString("  " + marshal(key) + ": " + marshal(value))
+		if !last {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("{\n")
+	writeEntry("@@locale", locale, len(messages) == 0)
+	for i, msg := range messages {
+		metadata := make(map[string]any)
+		if msg.Description != "" {
+			metadata["description"] = msg.Description
+		}
+		if len(msg.Placeholders) > 0 {
+			placeholders := make(map[string]any, len(msg.Placeholders))
+			for _, p := range msg.Placeholders {
+				placeholders[p.Name] = map[string]string{"type": p.Type}
+			}
+			metadata["placeholders"] = placeholders
+		}
+		last := i == len(messages)-1
+		writeEntry(msg.Key, msg.Value, last && len(metadata) == 0)
+		if len(metadata) > 0 {
+			writeEntry("@"+msg.Key, metadata, last)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
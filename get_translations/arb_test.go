@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestICUPlaceholders(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               string
+		want             string
+		wantPlaceholders []arbPlaceholder
+	}{
+		{name: "Python", in: "Drop %(filename)s here, %(count)d left", want: "Drop {filename} here, {count} left",
+			wantPlaceholders: []arbPlaceholder{{Name: "filename", Type: "String"}, {Name: "count", Type: "int"}}},
+		{name: "I18next", in: "Hi {{ name }}", want: "Hi {name}",
+			wantPlaceholders: []arbPlaceholder{{Name: "name", Type: "String"}}},
+		{name: "Positional", in: "%2$s by %1$s", want: "{arg2} by {arg1}",
+			wantPlaceholders: []arbPlaceholder{{Name: "arg2", Type: "String"}, {Name: "arg1", Type: "String"}}},
+		{name: "Plain", in: "%d%% of %.1f", want: "{arg1}% of {arg2}",
+			wantPlaceholders: []arbPlaceholder{{Name: "arg1", Type: "int"}, {Name: "arg2", Type: "double"}}},
+		{name: "None", in: "Save", want: "Save", wantPlaceholders: []arbPlaceholder{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, placeholders := icuPlaceholders(tt.in)
+			if got != tt.want {
+				t.Errorf("icuPlaceholders() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(placeholders, tt.wantPlaceholders) {
+				t.Errorf("placeholders = %v, want %v", placeholders, tt.wantPlaceholders)
+			}
+		})
+	}
+}
+
+func TestARBMessages(t *testing.T) {
+	loc := LocoLocale{Code: "pt-BR", Plurals: LocoPlurals{Length: 2, Forms: []string{"one", "other"}}}
+	assets := []LocoAsset{
+		{ID: "files.count", Notes: "number of files"},
+		{ID: "files.count-plural"},
+		{ID: "import.drop-here %(filename)s"},
+		{ID: "untranslated"},
+	}
+	translation := func(text string, translated bool, plurals ...LocoTranslationBase) []LocoTranslation {
+		return []LocoTranslation{{
+			LocoTranslationBase: LocoTranslationBase{Translation: text, Translated: translated, Plurals: plurals},
+			Locale:              LocoLocale{Code: "pt-BR"},
+		}}
+	}
+	translations := map[string][]LocoTranslation{
+		"files.count": translation("%(count)d arquivo", true,
+			LocoTranslationBase{ID: "files.count-plural", Translation: "%(count)d arquivos"}),
+		"files.count-plural":            translation("%(count)d arquivos", true),
+		"import.drop-here %(filename)s": translation("Solte %(filename)s aqui", true),
+		"untranslated":                  translation("", false),
+	}
+
+	want := `{
+  "@@locale": "pt_BR",
+  "filesCount": "{count, plural, one{{count} arquivo} other{{count} arquivos}}",
+  "@filesCount": {
+    "description": "number of files",
+    "placeholders": {
+      "count": {
+        "type": "int"
+      }
+    }
+  },
+  "importDropHere_Filename": "Solte {filename} aqui",
+  "@importDropHere_Filename": {
+    "placeholders": {
+      "filename": {
+        "type": "String"
+      }
+    }
+  }
+}
+`
+	got := string(encodeARB(arbLocaleCode(loc.Code), arbMessages(assets, translations, loc)))
+	if got != want {
+		t.Errorf("encodeARB() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -23,13 +23,16 @@ var (
 )
 
 type LocoAsset struct {
-	ID           string `json:"id"` // this is all we care about right now. actual loco json has much more info
-	GoIdentifier string `json:"-"`
+	ID           string   `json:"id"`
+	Tags         []string `json:"tags"`
+	Context      string   `json:"context"`
+	Notes        string   `json:"notes"`
+	GoIdentifier string   `json:"-"`
 }
 
 // pull down the assets from loco, and create a go file with all their names as constants
 func generateAssets(apiKey string, args []string) error {
-	locoAssets, err := getAssets(apiKey)
+	locoAssets, err := getAssets(apiKey, backendTag)
 	if err != nil {
 		return err
 	}
@@ -45,15 +48,18 @@ func generateAssets(apiKey string, args []string) error {
 
 	outFile, err := os.Create(args[0])
 	if err != nil {
-		return err
+		return run.record(args[0], err)
 	}
 	defer outFile.Close()
-	return tmpl.Execute(outFile, locoAssets)
+	return run.record(args[0], tmpl.Execute(outFile, locoAssets))
 }
 
-func getAssets(apiKey string) (assets []LocoAsset, err error) {
+// getAssets lists the assets in loco, optionally only the ones with the filter tag(s)
+func getAssets(apiKey, filter string) (assets []LocoAsset, err error) {
 	qp := url.Values{}
-	qp.Add(locoFilter, backendTag)
+	if filter != "" {
+		qp.Add(locoFilter, filter)
+	}
 	resp, err := locoRequest(apiKey, locoAssetsURL, qp)
 	if err != nil {
 		return
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	auditLanguageGo     = "go"
+	auditLanguageJS     = "js"
+	auditLanguageKotlin = "kotlin"
+	auditLanguageSwift  = "swift"
+)
+
+// auditReport is the result of comparing the assets with a tag to the source trees that use them
+type auditReport struct {
+	Tag string
+	// asset IDs that no source references
+	Unused []string
+	// keys the sources reference that aren't assets with the tag
+	Missing []extractedKey
+}
+
+// auditAssets compares the assets in loco with the keys used in the configured source trees, per tag
+func auditAssets(apiKey string, sources []AuditSource, strict bool) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no audit sources configured")
+	}
+
+	tags := make([]string, 0)
+	byTag := make(map[string][]AuditSource)
+	for _, source := range sources {
+		if _, ok := byTag[source.Tag]; !ok {
+			tags = append(tags, source.Tag)
+		}
+		byTag[source.Tag] = append(byTag[source.Tag], source)
+	}
+
+	reports := make([]auditReport, 0, len(tags))
+	for _, tag := range tags {
+		assets, err := getAssets(apiKey, tag)
+		if err != nil {
+			return err
+		}
+		report, err := auditTag(tag, assets, byTag[tag])
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+
+	problems := writeAuditReports(reportOut, reports)
+	if strict && problems > 0 {
+		return fmt.Errorf("%w: audit found %d unused or missing assets", errValidation, problems)
+	}
+	return nil
+}
+
+func auditTag(tag string, assets []LocoAsset, sources []AuditSource) (auditReport, error) {
+	// android resource names are derived from the asset IDs, so kotlin keys are compared with those
+	ids := newKeyCollector()
+	resourceNames := newKeyCollector()
+	for _, source := range sources {
+		var err error
+		switch source.Language {
+		case auditLanguageGo:
+			constants := make(map[string]string)
+			if source.AssetsFile != "" {
+				constants, err = assetConstants(source.AssetsFile)
+				if err != nil {
+					return auditReport{}, err
+				}
+			}
+			err = extractGoKeys(ids, source.Path, constants, source.AssetsFile)
+		case auditLanguageJS:
+			err = extractJSKeys(ids, source.Path)
+		case auditLanguageKotlin:
+			err = extractKotlinKeys(resourceNames, source.Path)
+		case auditLanguageSwift:
+			err = extractSwiftKeys(ids, source.Path)
+		default:
+			err = fmt.Errorf("unknown audit language %q for %s", source.Language, source.Path)
+		}
+		if err != nil {
+			return auditReport{}, err
+		}
+	}
+
+	report := auditReport{Tag: tag}
+	assetIDs := make(map[string]bool, len(assets))
+	assetResources := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		assetIDs[asset.ID] = true
+		resource := androidResourceName(asset.ID)
+		assetResources[resource] = true
+		_, usedID := ids.keys[asset.ID]
+		_, usedResource := resourceNames.keys[resource]
+		if !usedID && !usedResource {
+			report.Unused = append(report.Unused, asset.ID)
+		}
+	}
+	sort.Strings(report.Unused)
+
+	for _, key := range ids.sorted() {
+		if !assetIDs[key.ID] {
+			report.Missing = append(report.Missing, key)
+		}
+	}
+	for _, key := range resourceNames.sorted() {
+		if !assetResources[key.ID] {
+			report.Missing = append(report.Missing, key)
+		}
+	}
+	return report, nil
+}
+
+// writeAuditReports prints the reports and returns the number of problems in them
+func writeAuditReports(out io.Writer, reports []auditReport) int {
+	problems := 0
+	for _, report := range reports {
+		tag := report.Tag
+		if tag == "" {
+			tag = "(all assets)"
+		}
+		fmt.Fprintf(out, "%s: %d unused, %d missing\n", tag, len(report.Unused), len(report.Missing))
+		for _, id := range report.Unused {
+			fmt.Fprintf(out, "  unused   %s\n", id)
+		}
+		for _, key := range report.Missing {
+			fmt.Fprintf(out, "  missing  %s", key.ID)
+			if len(key.References) > 0 {
+				fmt.Fprintf(out, " (%s)", key.References[0])
+			}
+			fmt.Fprintln(out)
+		}
+		problems += len(report.Unused) + len(report.Missing)
+	}
+	return problems
+}
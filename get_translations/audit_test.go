@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAuditTag(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"locale/asset_ids.go": `package locale
+
+const (
+    Save = "save"
+    Cancel = "cancel"
+)
+`,
+		"api/handler.go": `package api
+
+import "example.com/app/locale"
+
+var buttons = []string{locale.Save}
+`,
+		// a local variable named like a constant isn't a use of it
+		"api/dialog.go": `package api
+
+func dialog() string {
+	Cancel := "dismiss"
+	return Cancel
+}
+`,
+		"web/app.ts":         `t('nav.home'); t("nav.gone")`,
+		"android/Main.kt":    `getString(R.string.menu_open)`,
+		"ios/View.swift":     `Text(String(localized: "greeting"))`,
+		"ios/Other.swift":    `let x = NSLocalizedString("farewell", comment: "")`,
+		"android/Unused.kts": `// R.string is referenced, but not an asset`,
+	})
+
+	tests := []struct {
+		name        string
+		sources     []AuditSource
+		wantUnused  []string
+		wantMissing []string
+	}{
+		{
+			name: "Go",
+			sources: []AuditSource{{
+				Path:       dir,
+				Language:   auditLanguageGo,
+				AssetsFile: filepath.Join(dir, "locale", "asset_ids.go"),
+			}},
+			wantUnused: []string{"cancel", "greeting", "menu.open", "nav.home"},
+		},
+		{
+			name: "Mixed",
+			sources: []AuditSource{
+				{Path: filepath.Join(dir, "web"), Language: auditLanguageJS},
+				{Path: filepath.Join(dir, "android"), Language: auditLanguageKotlin},
+				{Path: filepath.Join(dir, "ios"), Language: auditLanguageSwift},
+			},
+			wantUnused:  []string{"cancel", "save"},
+			wantMissing: []string{"farewell", "nav.gone"},
+		},
+	}
+
+	assets := []LocoAsset{{ID: "save"}, {ID: "cancel"}, {ID: "nav.home"}, {ID: "menu.open"}, {ID: "greeting"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := auditTag("", assets, tt.sources)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(report.Unused, tt.wantUnused) {
+				t.Errorf("unused = %v, want %v", report.Unused, tt.wantUnused)
+			}
+			if got := keyIDs(report.Missing); len(got) > 0 || len(tt.wantMissing) > 0 {
+				if !reflect.DeepEqual(got, tt.wantMissing) {
+					t.Errorf("missing = %v, want %v", got, tt.wantMissing)
+				}
+			}
+		})
+	}
+}
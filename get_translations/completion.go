@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// completionGate holds back the locales of an export that are less translated than the minimum, so they aren't
+// shipped as mostly source language fallbacks. a nil gate lets every locale through.
+type completionGate struct {
+	min float64
+	tag string
+	// completion of the held back locales, keyed by their loco code
+	held map[string]float64
+	// held back locales by normalized code, so that e.g. de_DE from a file name matches de-DE
+	heldKeys map[string]string
+}
+
+// newCompletionGate works out which locales have less than min percent of the assets with tag, the ones the export
+// has, translated. it returns nil when min is 0. without a tag that's the progress loco keeps for each locale, which
+// comes with the locales; with one, the assets with the tag are counted against an export of only their translated
+// strings, so it's two requests rather than one for every asset.
+func newCompletionGate(apiKey, tag string, min float64) (*completionGate, error) {
+	if min <= 0 {
+		return nil, nil
+	}
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return newCompletionGateFromReport(progressCoverage(allLocales, min), tag), nil
+	}
+	assets, err := getAssets(apiKey, tag)
+	if err != nil {
+		return nil, err
+	}
+	translated, err := getTranslatedCounts(apiKey, tag)
+	if err != nil {
+		return nil, err
+	}
+	return newCompletionGateFromReport(tagCoverage(allLocales, len(assets), translated, min), tag), nil
+}
+
+// getTranslatedCounts is how many of the assets with tag are translated in each locale, by normalized locale code
+func getTranslatedCounts(apiKey, tag string) (map[string]int, error) {
+	qp := url.Values{}
+	qp.Add(locoFilter, tag)
+	qp.Add("status", "translated")
+	qp.Add("index", "id")
+	resp, err := locoRequest(apiKey, locoJsonExportURL, qp)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return countTranslated(resp.Body)
+}
+
+// countTranslated counts the strings of each locale in a json export of every locale
+func countTranslated(r io.Reader) (map[string]int, error) {
+	var locales map[string]interface{}
+	err := json.NewDecoder(r).Decode(&locales)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the translated strings: %w", err)
+	}
+	counts := make(map[string]int, len(locales))
+	for code, translations := range locales {
+		counts[completionKey(code)] = countStrings(translations)
+	}
+	return counts, nil
+}
+
+// countStrings counts the non-empty strings in v, which can be nested by the dots in asset IDs
+func countStrings(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		if v != "" {
+			return 1
+		}
+	case map[string]interface{}:
+		n := 0
+		for _, child := range v {
+			n += countStrings(child)
+		}
+		return n
+	}
+	return 0
+}
+
+// tagCoverage is the coverage report of the locales from the number of assets with a tag and how many of them each
+// locale has translated
+func tagCoverage(allLocales []LocoLocale, total int, translated map[string]int, threshold float64) coverageReport {
+	report := coverageReport{Threshold: threshold, Tags: []string{}}
+	for _, loc := range allLocales {
+		if loc.Source {
+			continue
+		}
+		lc := localeCoverage{Locale: loc.Code, Name: loc.Name, Tags: make(map[string]*coverageStat),
+			Overall: coverageStat{Translated: translated[completionKey(loc.Code)], Total: total, Percent: 100}}
+		if lc.Overall.Translated > total {
+			// plural forms can be counted as strings of their own
+			lc.Overall.Translated = total
+		}
+		if total > 0 {
+			lc.Overall.Percent = float64(lc.Overall.Translated) * 100 / float64(total)
+		}
+		lc.BelowThreshold = threshold > 0 && lc.Overall.Percent < threshold
+		report.Locales = append(report.Locales, lc)
+	}
+	sort.Slice(report.Locales, func(i, j int) bool { return report.Locales[i].Locale < report.Locales[j].Locale })
+	return report
+}
+
+// progressCoverage is the coverage report of the locales from their loco progress, without any tags
+func progressCoverage(allLocales []LocoLocale, threshold float64) coverageReport {
+	report := coverageReport{Threshold: threshold, Tags: []string{}}
+	for _, loc := range allLocales {
+		if loc.Source {
+			continue
+		}
+		total := loc.Progress.Translated + loc.Progress.Untranslated
+		lc := localeCoverage{Locale: loc.Code, Name: loc.Name, Tags: make(map[string]*coverageStat),
+			Overall: coverageStat{Translated: loc.Progress.Translated, Total: total, Percent: loc.Progress.Percent()}}
+		lc.BelowThreshold = threshold > 0 && lc.Overall.Percent < threshold
+		report.Locales = append(report.Locales, lc)
+	}
+	sort.Slice(report.Locales, func(i, j int) bool { return report.Locales[i].Locale < report.Locales[j].Locale })
+	return report
+}
+
+func newCompletionGateFromReport(report coverageReport, tag string) *completionGate {
+	g := &completionGate{
+		min:      report.Threshold,
+		tag:      tag,
+		held:     make(map[string]float64),
+		heldKeys: make(map[string]string),
+	}
+	for _, loc := range report.Locales {
+		if loc.BelowThreshold {
+			g.held[loc.Locale] = loc.Overall.Percent
+			g.heldKeys[completionKey(loc.Locale)] = loc.Locale
+		}
+	}
+	return g
+}
+
+// completionKey normalizes a locale code from loco, a file name or a directory name
+func completionKey(locale string) string {
+	locale = strings.ReplaceAll(normalizeScript(locale), "_", "-")
+	if tag, err := language.Parse(locale); err == nil {
+		return tag.String()
+	}
+	return strings.ToLower(locale)
+}
+
+// allows reports if the locale can be written. locales the gate doesn't know about are allowed.
+func (g *completionGate) allows(locale string) bool {
+	if g == nil {
+		return true
+	}
+	_, held := g.heldKeys[completionKey(locale)]
+	return !held
+}
+
+// heldLocales returns the loco codes of the held back locales
+func (g *completionGate) heldLocales() []string {
+	if g == nil {
+		return nil
+	}
+	codes := make([]string, 0, len(g.held))
+	for code := range g.held {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// report logs the locales that were held back and why
+func (g *completionGate) report() {
+	for _, code := range g.heldLocales() {
+		reason := fmt.Sprintf("%.1f%% translated, below the minimum of %.1f%%", g.held[code], g.min)
+		slog.Warn("held back locale", slog.String("locale", code), slog.String("reason", reason),
+			slog.String("tag", g.tag))
+		run.skippedLocale(code, reason)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompletionGate(t *testing.T) {
+	report := coverageReport{
+		Threshold: 80,
+		Locales: []localeCoverage{
+			{Locale: "de-DE", Overall: coverageStat{Percent: 95}},
+			{Locale: "pt-BR", Overall: coverageStat{Percent: 40}, BelowThreshold: true},
+			{Locale: "sr-Latn", Overall: coverageStat{Percent: 10}, BelowThreshold: true},
+		},
+	}
+	gate := newCompletionGateFromReport(report, "")
+
+	tests := []struct {
+		locale string
+		want   bool
+	}{
+		{locale: "de-DE", want: true},
+		{locale: "pt-BR", want: false},
+		// po archive directory
+		{locale: "pt_BR", want: false},
+		{locale: "sr@latin", want: true},
+		{locale: "sr@Latn", want: false},
+		// android resource directory locale and the source locale's values directory
+		{locale: "sr-Latn", want: false},
+		{locale: "", want: true},
+		{locale: "fr", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := gate.allows(tt.locale); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.locale, got, tt.want)
+			}
+		})
+	}
+
+	if got := gate.heldLocales(); !reflect.DeepEqual(got, []string{"pt-BR", "sr-Latn"}) {
+		t.Errorf("heldLocales() = %v", got)
+	}
+
+	var nilGate *completionGate
+	if !nilGate.allows("pt-BR") || nilGate.heldLocales() != nil {
+		t.Error("a nil gate should allow everything")
+	}
+}
+
+func TestProgressCoverage(t *testing.T) {
+	allLocales := []LocoLocale{
+		{Code: "en-US", Source: true, Progress: LocoProgress{Translated: 10}},
+		{Code: "pt-BR", Progress: LocoProgress{Translated: 4, Untranslated: 6, Flagged: 1}},
+		{Code: "de-DE", Progress: LocoProgress{Translated: 9, Untranslated: 1}},
+		{Code: "fr-FR"},
+	}
+	report := progressCoverage(allLocales, 80)
+	want := []localeCoverage{
+		{Locale: "de-DE", Overall: coverageStat{Translated: 9, Total: 10, Percent: 90}},
+		{Locale: "fr-FR", Overall: coverageStat{Percent: 100}},
+		{Locale: "pt-BR", Overall: coverageStat{Translated: 4, Total: 10, Percent: 40}, BelowThreshold: true},
+	}
+	if len(report.Locales) != len(want) {
+		t.Fatalf("locales = %+v, want %+v", report.Locales, want)
+	}
+	for i, got := range report.Locales {
+		if got.Locale != want[i].Locale || got.Overall != want[i].Overall || got.BelowThreshold != want[i].BelowThreshold {
+			t.Errorf("locale %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if got := newCompletionGateFromReport(report, "").heldLocales(); !reflect.DeepEqual(got, []string{"pt-BR"}) {
+		t.Errorf("heldLocales() = %v, want [pt-BR]", got)
+	}
+}
+
+func TestTagCoverage(t *testing.T) {
+	// the translated strings of the assets with the tag, one of them nested by the dot in its ID
+	export := `{
+		"de-DE": {"hello": "Hallo", "bye": "Tschüss", "menu": {"open": "Öffnen"}},
+		"pt_BR": {"hello": "Olá", "bye": ""},
+		"en-US": {"hello": "Hello", "bye": "Bye", "menu": {"open": "Open"}}
+	}`
+	translated, err := countTranslated(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allLocales := []LocoLocale{
+		{Code: "en-US", Source: true},
+		// fully translated in the project, but not the tag
+		{Code: "pt-BR", Progress: LocoProgress{Translated: 100}},
+		{Code: "de-DE", Progress: LocoProgress{Translated: 1, Untranslated: 99}},
+		{Code: "fr-FR"},
+	}
+	report := tagCoverage(allLocales, 3, translated, 50)
+	want := []localeCoverage{
+		{Locale: "de-DE", Overall: coverageStat{Translated: 3, Total: 3, Percent: 100}},
+		{Locale: "fr-FR", Overall: coverageStat{Total: 3}, BelowThreshold: true},
+		{Locale: "pt-BR", Overall: coverageStat{Translated: 1, Total: 3, Percent: 100.0 / 3}, BelowThreshold: true},
+	}
+	if len(report.Locales) != len(want) {
+		t.Fatalf("locales = %+v, want %+v", report.Locales, want)
+	}
+	for i, got := range report.Locales {
+		if got.Locale != want[i].Locale || got.Overall != want[i].Overall || got.BelowThreshold != want[i].BelowThreshold {
+			t.Errorf("locale %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	got := newCompletionGateFromReport(report, "mobile").heldLocales()
+	if !reflect.DeepEqual(got, []string{"fr-FR", "pt-BR"}) {
+		t.Errorf("heldLocales() = %v, want [fr-FR pt-BR]", got)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds the settings that are too involved for command line flags. it's read from the file given with
+// --config.
+type Config struct {
+	Android AndroidConfig `yaml:"android"`
+	Audit   AuditConfig   `yaml:"audit"`
+	IOS     IOSConfig     `yaml:"ios"`
+	// loco projects to export in one run, each into its own directory and with its own API key. only the project of
+	// the default read only API key is exported when there are none.
+	Projects []ProjectConfig `yaml:"projects"`
+}
+
+// ProjectConfig is a loco project to export. its read only API key is read from the file, or else the environment
+// variable, or else asked from the --credentials-helper with read and the project's name.
+type ProjectConfig struct {
+	// a file with the project's read only API key
+	APIKeyFile string `yaml:"api_key_file"`
+	// the environment variable with the project's read only API key
+	APIKeyVar string `yaml:"api_key_env"`
+	// the project's name in loco's URL for it; asked from loco when empty
+	Name string `yaml:"name"`
+	// output directory relative to the one on the command line; the project name when empty
+	Dir string `yaml:"dir"`
+}
+
+// describe names the project in messages, before loco was asked for its name
+func (p ProjectConfig) describe() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.APIKeyVar != "":
+		return "with the key in " + p.APIKeyVar
+	case p.APIKeyFile != "":
+		return "with the key in " + p.APIKeyFile
+	}
+	return "without a name or key"
+}
+
+type AndroidConfig struct {
+	// when modules are configured, the android base dir is the project root and each module gets only its strings
+	Modules []AndroidModule `yaml:"modules"`
+}
+
+type AndroidModule struct {
+	Name string `yaml:"name"`
+	// resource directory relative to the android base dir, e.g. feature-x/src/main/res
+	ResDir string `yaml:"res"`
+	// assets with any of these loco tags belong to the module
+	Tags []string `yaml:"tags"`
+	// assets whose ID starts with any of these belong to the module
+	Prefixes []string `yaml:"prefixes"`
+	// write xml/locales_config.xml into this module when --locales-config is set
+	LocalesConfig bool `yaml:"locales_config"`
+}
+
+type IOSConfig struct {
+	// the rules Info.plist values are checked against before InfoPlist.xcstrings is written, by plist key, e.g.
+	// CFBundleDisplayName. they replace the built in rule for the key.
+	PlistRules map[string]plistRule `yaml:"plist_rules"`
+	// the rule for the plist keys without one; the built in default when not set
+	PlistDefaultRule *plistRule `yaml:"plist_default_rule"`
+}
+
+type AuditConfig struct {
+	Sources []AuditSource `yaml:"sources"`
+}
+
+// AuditSource is a source tree that uses the assets with a loco tag
+type AuditSource struct {
+	Path string `yaml:"path"`
+	// go, js, kotlin or swift
+	Language string `yaml:"language"`
+	// the loco tag of the assets the source uses; empty for every asset
+	Tag string `yaml:"tag"`
+	// for go, the generated asset_ids.go whose constants the source uses
+	AssetsFile string `yaml:"assets_file"`
+}
+
+func loadConfig(path string) (config Config, err error) {
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = yaml.UnmarshalStrict(data, &config)
+	return
+}
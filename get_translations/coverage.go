@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+const (
+	coverageFormatTable    = "table"
+	coverageFormatJSON     = "json"
+	coverageFormatMarkdown = "markdown"
+	// concurrent requests for the translations of each asset
+	translationWorkers = 8
+	badgeURL           = "https://img.shields.io/badge/%s-%s-%s"
+)
+
+type coverageOptions struct {
+	// only report these tags. every tag on an asset when empty
+	Tags []string
+	// percent below which a locale is flagged
+	Threshold float64
+	// table, json or markdown
+	Format string
+}
+
+type coverageStat struct {
+	Translated int     `json:"translated"`
+	Total      int     `json:"total"`
+	Percent    float64 `json:"percent"`
+}
+
+func (s *coverageStat) add(translated bool) {
+	s.Total++
+	if translated {
+		s.Translated++
+	}
+	s.Percent = float64(s.Translated) * 100 / float64(s.Total)
+}
+
+type localeCoverage struct {
+	Locale         string                   `json:"locale"`
+	Name           string                   `json:"name"`
+	Overall        coverageStat             `json:"overall"`
+	Tags           map[string]*coverageStat `json:"tags"`
+	BelowThreshold bool                     `json:"below_threshold"`
+}
+
+type coverageReport struct {
+	Threshold float64          `json:"threshold"`
+	Tags      []string         `json:"tags"`
+	Locales   []localeCoverage `json:"locales"`
+}
+
+// reportCoverage fetches every translation and reports how complete each locale is, overall and per tag. an error is
+// returned when a locale is below the threshold, after the report is written.
+func reportCoverage(apiKey string, opts coverageOptions) error {
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	assets, err := getAssets(apiKey, "")
+	if err != nil {
+		return err
+	}
+	assets = assetsWithTags(assets, opts.Tags)
+	translations, err := fetchAllTranslations(apiKey, assets)
+	if err != nil {
+		return err
+	}
+
+	report := computeCoverage(allLocales, assets, translations, opts)
+	err = writeCoverage(reportOut, report, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	below := make([]string, 0)
+	for _, loc := range report.Locales {
+		if loc.BelowThreshold {
+			below = append(below, loc.Locale)
+		}
+	}
+	if len(below) > 0 {
+		return fmt.Errorf("%w: locales below %.0f%% translated: %s", errValidation, opts.Threshold, strings.Join(below, ", "))
+	}
+	return nil
+}
+
+// fetchAllTranslations gets the translations of every asset, keyed by asset ID
+func fetchAllTranslations(apiKey string, assets []LocoAsset) (map[string][]LocoTranslation, error) {
+	ids := make(chan string)
+	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
+	all := make(map[string][]LocoTranslation, len(assets))
+	var firstErr error
+	for i := 0; i < translationWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				translations, err := getTranslations(apiKey, id)
+				mu.Lock()
+				if err != nil {
+					slog.Error("error getting translations", slog.String("id", id), slog.Any("err", err))
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					all[id] = translations
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, asset := range assets {
+		ids <- asset.ID
+	}
+	close(ids)
+	wg.Wait()
+	return all, firstErr
+}
+
+func getTranslations(apiKey, assetID string) (translations []LocoTranslation, err error) {
+	resp, err := locoRequest(apiKey, fmt.Sprintf(locoJsonTranslationsURL, url.PathEscape(assetID)), url.Values{})
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&translations)
+	return
+}
+
+func computeCoverage(allLocales []LocoLocale, assets []LocoAsset, translations map[string][]LocoTranslation,
+	opts coverageOptions) coverageReport {
+	report := coverageReport{Threshold: opts.Threshold, Tags: coverageTags(assets, opts.Tags)}
+	for _, loc := range allLocales {
+		if loc.Source {
+			continue
+		}
+		lc := localeCoverage{Locale: loc.Code, Name: loc.Name, Tags: make(map[string]*coverageStat)}
+		for _, tag := range report.Tags {
+			lc.Tags[tag] = &coverageStat{}
+		}
+		for _, asset := range assets {
+			translated := false
+			for _, translation := range translations[asset.ID] {
+				if translation.Locale.Code == loc.Code {
+					translated = translation.Translated
+					break
+				}
+			}
+			lc.Overall.add(translated)
+			for _, tag := range asset.Tags {
+				if stat, ok := lc.Tags[tag]; ok {
+					stat.add(translated)
+				}
+			}
+		}
+		lc.BelowThreshold = opts.Threshold > 0 && lc.Overall.Percent < opts.Threshold
+		report.Locales = append(report.Locales, lc)
+	}
+	sort.Slice(report.Locales, func(i, j int) bool { return report.Locales[i].Locale < report.Locales[j].Locale })
+	return report
+}
+
+// assetsWithTags keeps the assets with any of the tags, or all of them when there are no tags
+func assetsWithTags(assets []LocoAsset, tags []string) []LocoAsset {
+	if len(tags) == 0 {
+		return assets
+	}
+	filtered := make([]LocoAsset, 0, len(assets))
+	for _, asset := range assets {
+		if slices.ContainsFunc(asset.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			filtered = append(filtered, asset)
+		}
+	}
+	return filtered
+}
+
+// coverageTags is the tags to report: the requested ones, or all the tags on the assets
+func coverageTags(assets []LocoAsset, requested []string) []string {
+	if len(requested) > 0 {
+		return requested
+	}
+	tags := make([]string, 0)
+	for _, asset := range assets {
+		for _, tag := range asset.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func writeCoverage(out io.Writer, report coverageReport, format string) error {
+	switch format {
+	case "", coverageFormatTable:
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		header := append([]string{"LOCALE", "OVERALL"}, report.Tags...)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t"))+"\t")
+		for _, loc := range report.Locales {
+			flag := ""
+			if loc.BelowThreshold {
+				flag = "below threshold"
+			}
+			row := append([]string{loc.Locale, formatStat(loc.Overall)}, tagStats(loc, report.Tags)...)
+			fmt.Fprintln(tw, strings.Join(append(row, flag), "\t"))
+		}
+		return tw.Flush()
+	case coverageFormatJSON:
+		je := json.NewEncoder(out)
+		je.SetIndent("", "  ")
+		return je.Encode(report)
+	case coverageFormatMarkdown:
+		header := append([]string{"Locale", "Overall"}, report.Tags...)
+		fmt.Fprintf(out, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(out, "|%s\n", strings.Repeat("---|", len(header)))
+		for _, loc := range report.Locales {
+			row := append([]string{loc.Locale, coverageBadge(loc)}, tagStats(loc, report.Tags)...)
+			fmt.Fprintf(out, "| %s |\n", strings.Join(row, " | "))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown coverage format: %s", format)
+}
+
+func formatStat(stat coverageStat) string {
+	if stat.Total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", stat.Percent)
+}
+
+func tagStats(loc localeCoverage, tags []string) []string {
+	stats := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		stats = append(stats, formatStat(*loc.Tags[tag]))
+	}
+	return stats
+}
+
+// coverageBadge is a shields.io badge for the overall percentage
+func coverageBadge(loc localeCoverage) string {
+	color := "brightgreen"
+	switch {
+	case loc.BelowThreshold:
+		color = "red"
+	case loc.Overall.Percent < 100:
+		color = "yellow"
+	}
+	// shields.io uses - as the separator, so a literal - is doubled
+	label := strings.ReplaceAll(loc.Locale, "-", "--")
+	percent := url.PathEscape(fmt.Sprintf("%.0f%%", loc.Overall.Percent))
+	return fmt.Sprintf("![%s %.0f%%](%s)", loc.Locale, loc.Overall.Percent, fmt.Sprintf(badgeURL, label, percent, color))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testTranslations(translated map[string]bool) []LocoTranslation {
+	translations := make([]LocoTranslation, 0, len(translated))
+	for code, ok := range translated {
+		translations = append(translations, LocoTranslation{
+			LocoTranslationBase: LocoTranslationBase{Translated: ok},
+			Locale:              LocoLocale{Code: code},
+		})
+	}
+	return translations
+}
+
+func TestComputeCoverage(t *testing.T) {
+	allLocales := []LocoLocale{{Code: "en-US", Source: true}, {Code: "de"}, {Code: "fr"}}
+	assets := []LocoAsset{
+		{ID: "a", Tags: []string{"backend"}},
+		{ID: "b", Tags: []string{"backend", "mobile-apps"}},
+		{ID: "c", Tags: []string{"mobile-apps"}},
+		{ID: "d"},
+	}
+	translations := map[string][]LocoTranslation{
+		"a": testTranslations(map[string]bool{"de": true, "fr": true}),
+		"b": testTranslations(map[string]bool{"de": true, "fr": false}),
+		"c": testTranslations(map[string]bool{"de": true}),
+		"d": testTranslations(map[string]bool{"de": false}),
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		overall     float64
+		tag         string
+		tagPercent  float64
+		belowThresh bool
+	}{
+		{name: "German", locale: "de", overall: 75, tag: "mobile-apps", tagPercent: 100},
+		{name: "French", locale: "fr", overall: 25, tag: "backend", tagPercent: 50, belowThresh: true},
+	}
+
+	report := computeCoverage(allLocales, assets, translations, coverageOptions{Threshold: 50})
+	if len(report.Locales) != 2 {
+		t.Fatalf("got %d locales, want 2 without the source locale", len(report.Locales))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, loc := range report.Locales {
+				if loc.Locale != tt.locale {
+					continue
+				}
+				if loc.Overall.Percent != tt.overall {
+					t.Errorf("overall = %v, want %v", loc.Overall.Percent, tt.overall)
+				}
+				if got := loc.Tags[tt.tag].Percent; got != tt.tagPercent {
+					t.Errorf("%s = %v, want %v", tt.tag, got, tt.tagPercent)
+				}
+				if loc.BelowThreshold != tt.belowThresh {
+					t.Errorf("below threshold = %v, want %v", loc.BelowThreshold, tt.belowThresh)
+				}
+				return
+			}
+			t.Errorf("no coverage for %s", tt.locale)
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := writeCoverage(&buf, report, coverageFormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	want := "| fr | ![fr 25%](https://img.shields.io/badge/fr-25%25-red) | 50.0% | 0.0% |"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("markdown is missing %q:\n%s", want, buf.String())
+	}
+}
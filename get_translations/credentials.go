@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	scopeRead  = "read"
+	scopeWrite = "write"
+
+	// the annotation on a command listing the key scopes it needs, separated by commas
+	keyScopesAnnotation = "loco_key_scopes"
+	// the annotation on a command that exports the projects in the config file, each with its own key
+	projectKeysAnnotation = "loco_project_keys"
+
+	locoTagsURL = locoBaseURL + "/tags"
+)
+
+type credentialOptions struct {
+	// files holding the read only and the writing key, e.g. mounted kubernetes secrets
+	ReadKeyFile  string
+	WriteKeyFile string
+	// a command that prints a key; it's run with the scope, read or write, as its last argument
+	Helper string
+}
+
+// requireKeys marks the commands as needing API keys with the scopes
+func requireKeys(scopes []string, cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[keyScopesAnnotation] = strings.Join(scopes, ",")
+	}
+}
+
+// exportsProjects marks the commands as exporting the projects in the config file when it has any. those have keys
+// of their own, so the default read only key is only needed without them.
+func exportsProjects(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[projectKeysAnnotation] = "true"
+	}
+}
+
+// usesProjectKeys tells whether cmd was marked by exportsProjects
+func usesProjectKeys(cmd *cobra.Command) bool {
+	return cmd.Annotations[projectKeysAnnotation] != ""
+}
+
+// requiredScopes is the key scopes cmd needs. a command run with --dry-run doesn't write, so it doesn't need a key
+// that allows writing.
+func requiredScopes(cmd *cobra.Command) []string {
+	annotation := cmd.Annotations[keyScopesAnnotation]
+	if annotation == "" {
+		return nil
+	}
+	dryRun := false
+	if flag := cmd.Flags().Lookup("dry-run"); flag != nil {
+		dryRun = flag.Value.String() == "true"
+	}
+	scopes := make([]string, 0, 2)
+	for _, scope := range strings.Split(annotation, ",") {
+		if scope == scopeWrite && dryRun {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// keys finds the read only and writing keys for the scopes, failing when one that's needed is missing. a key that
+// allows writing can also read, so it stands in for a missing read only key.
+func (c credentialOptions) keys(scopes []string) (readKey, writeKey string, err error) {
+	needRead := slices.Contains(scopes, scopeRead)
+	needWrite := slices.Contains(scopes, scopeWrite)
+	if needRead {
+		readKey, _, err = c.resolve(scopeRead)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if needWrite || (needRead && readKey == "") {
+		writeKey, _, err = c.resolve(scopeWrite)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if needRead && readKey == "" {
+		if writeKey == "" {
+			return "", "", c.missing(scopeRead)
+		}
+		slog.Debug("no read only api key, using the one that allows writing")
+		readKey = writeKey
+	}
+	if needWrite && writeKey == "" {
+		return "", "", c.missing(scopeWrite)
+	}
+	return readKey, writeKey, nil
+}
+
+// resolve finds the key for scope in its file, then its environment variable, then from the helper. it returns the
+// key, which is empty when there isn't one, and where it came from.
+func (c credentialOptions) resolve(scope string) (key, source string, err error) {
+	envVar, file := apiKeyVar, c.ReadKeyFile
+	if scope == scopeWrite {
+		envVar, file = writeAPIKeyVar, c.WriteKeyFile
+	}
+	return c.resolveKey(file, envVar, scope)
+}
+
+// projectKey finds the read only key of a project in the config file in the project's key file, then its environment
+// variable, then from the helper, which is run with read and the project's name as its last arguments
+func (c credentialOptions) projectKey(p ProjectConfig) (key, source string, err error) {
+	helperArgs := []string{scopeRead, p.Name}
+	if p.Name == "" {
+		// the helper couldn't tell the projects apart
+		helperArgs = nil
+	}
+	key, source, err = c.resolveKey(p.APIKeyFile, p.APIKeyVar, helperArgs...)
+	if err != nil || key != "" {
+		return key, source, err
+	}
+	return "", "", fmt.Errorf("%w: missing api key for the project %s: provide it in its api_key_file or api_key_env, "+
+		"or name the project for --credentials-helper", errAuth, p.describe())
+}
+
+// resolveKey reads the key from file when it's set, or else from envVar, or else from the helper run with
+// helperArgs. the helper isn't run without arguments.
+func (c credentialOptions) resolveKey(file, envVar string, helperArgs ...string) (key, source string, err error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", "", fmt.Errorf("reading the api key: %w", err)
+		}
+		key = strings.TrimSpace(string(data))
+		if key == "" {
+			return "", "", fmt.Errorf("%w: the api key file %s is empty", errAuth, file)
+		}
+		return key, "file " + file, nil
+	}
+	if envVar != "" {
+		if key = os.Getenv(envVar); key != "" {
+			return key, "environment variable " + envVar, nil
+		}
+	}
+	if c.Helper != "" && len(helperArgs) > 0 {
+		key, err = runCredentialHelper(c.Helper, helperArgs...)
+		if err != nil {
+			return "", "", err
+		}
+		if key != "" {
+			return key, "credentials helper", nil
+		}
+	}
+	return "", "", nil
+}
+
+func (c credentialOptions) missing(scope string) error {
+	envVar, fileFlag := apiKeyVar, "--api-key-file"
+	if scope == scopeWrite {
+		envVar, fileFlag = writeAPIKeyVar, "--write-api-key-file"
+	}
+	return fmt.Errorf("%w: missing %s api key: provide it in the environment variable %s, with %s or from "+
+		"--credentials-helper", errAuth, scope, envVar, fileFlag)
+}
+
+// runCredentialHelper runs the helper command with args, e.g. the scope, as its last arguments and returns what it
+// prints. the command is split on spaces and isn't run through a shell.
+func runCredentialHelper(helper string, args ...string) (string, error) {
+	command := strings.Fields(helper)
+	if len(command) == 0 {
+		return "", fmt.Errorf("empty credentials helper")
+	}
+	var stdout bytes.Buffer
+	cmd := exec.Command(command[0], append(command[1:], args...)...) // #nosec G204 // the helper is the user's own command
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("%w: credentials helper run with %s: %v", errAuth, strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// whoami reports which project each configured key is for and whether it can write. loco's /auth/verify doesn't tell a
+// key's access level, so whoami probes it with keyCanWrite.
+func whoami(out io.Writer, c credentialOptions) error {
+	found := 0
+	for _, scope := range []string{scopeRead, scopeWrite} {
+		key, source, err := c.resolve(scope)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			fmt.Fprintf(out, "%s key: not set\n", scope)
+			continue
+		}
+		found++
+		project, err := getLocoProject(key)
+		if err != nil {
+			return fmt.Errorf("%s key from %s: %w", scope, source, err)
+		}
+		canWrite, err := keyCanWrite(key, locoTagsURL)
+		if err != nil {
+			return fmt.Errorf("%s key from %s: %w", scope, source, err)
+		}
+		access := "read only"
+		if canWrite {
+			access = "can write"
+		}
+		fmt.Fprintf(out, "%s key: from %s, for project %s (%s, id %d), %s\n", scope, source, project.Name,
+			project.Slug(), project.ID, access)
+	}
+	if found == 0 {
+		return c.missing(scopeRead)
+	}
+	return nil
+}
+
+// keyCanWrite tells whether loco lets the key write, by creating a tag without a name. loco checks the key's access
+// before the request, so a read only key gets 401/403 and a key that can write gets the invalid tag rejected, which
+// leaves the project as it was.
+func keyCanWrite(apiKey, URL string) (bool, error) {
+	resp, err := locoWriteForm(apiKey, URL, http.MethodPost, url.Values{"name": {""}})
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, errAuth):
+		return false, nil
+	case resp != nil && resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError &&
+		resp.StatusCode != http.StatusTooManyRequests:
+		return true, nil
+	}
+	return false, err
+}
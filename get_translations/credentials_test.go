@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRequiredScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		args   []string
+		want   []string
+	}{
+		{name: "None", want: nil},
+		{name: "Read", scopes: []string{scopeRead}, want: []string{scopeRead}},
+		{name: "ReadWrite", scopes: []string{scopeRead, scopeWrite}, want: []string{scopeRead, scopeWrite}},
+		{name: "DryRun", scopes: []string{scopeRead, scopeWrite}, args: []string{"--dry-run"}, want: []string{scopeRead}},
+		{name: "WriteDryRun", scopes: []string{scopeWrite}, args: []string{"--dry-run"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().Bool("dry-run", false, "")
+			if tt.scopes != nil {
+				requireKeys(tt.scopes, cmd)
+			}
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := requiredScopes(cmd); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requiredScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialKeys(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("file-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      credentialOptions
+		readEnv   string
+		writeEnv  string
+		scopes    []string
+		wantRead  string
+		wantWrite string
+		wantErr   error
+	}{
+		{name: "Env", readEnv: "ro", writeEnv: "rw", scopes: []string{scopeRead}, wantRead: "ro"},
+		{name: "EnvBoth", readEnv: "ro", writeEnv: "rw", scopes: []string{scopeRead, scopeWrite}, wantRead: "ro",
+			wantWrite: "rw"},
+		{name: "FileFirst", opts: credentialOptions{ReadKeyFile: keyFile}, readEnv: "ro", scopes: []string{scopeRead},
+			wantRead: "file-key"},
+		{name: "Helper", opts: credentialOptions{Helper: "printf %s-key"}, scopes: []string{scopeRead, scopeWrite},
+			wantRead: "read-key", wantWrite: "write-key"},
+		{name: "WriteStandsIn", writeEnv: "rw", scopes: []string{scopeRead}, wantRead: "rw", wantWrite: "rw"},
+		{name: "MissingRead", scopes: []string{scopeRead}, wantErr: errAuth},
+		{name: "MissingWrite", readEnv: "ro", scopes: []string{scopeWrite}, wantErr: errAuth},
+		{name: "NoScopes"},
+		{name: "FailingHelper", opts: credentialOptions{Helper: "false"}, scopes: []string{scopeRead}, wantErr: errAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(apiKeyVar, tt.readEnv)
+			t.Setenv(writeAPIKeyVar, tt.writeEnv)
+			read, write, err := tt.opts.keys(tt.scopes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("keys() error = %v, want %v", err, tt.wantErr)
+			}
+			if read != tt.wantRead || write != tt.wantWrite {
+				t.Errorf("keys() = %q, %q, want %q, %q", read, write, tt.wantRead, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestProjectKey(t *testing.T) {
+	t.Setenv("TEST_LOCO_KEY_WEB", "web-env")
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("web-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       credentialOptions
+		project    ProjectConfig
+		want       string
+		wantSource string
+		wantErr    error
+	}{
+		{name: "File", project: ProjectConfig{APIKeyFile: keyFile, APIKeyVar: "TEST_LOCO_KEY_WEB"}, want: "web-file",
+			wantSource: "file " + keyFile},
+		{name: "Env", opts: credentialOptions{Helper: "printf %s-%s"},
+			project: ProjectConfig{APIKeyVar: "TEST_LOCO_KEY_WEB", Name: "web"}, want: "web-env",
+			wantSource: "environment variable TEST_LOCO_KEY_WEB"},
+		{name: "Helper", opts: credentialOptions{Helper: "printf %s-%s"}, project: ProjectConfig{Name: "web"},
+			want: "read-web", wantSource: "credentials helper"},
+		// the helper couldn't tell which project it's asked for
+		{name: "HelperWithoutName", opts: credentialOptions{Helper: "printf %s-%s"},
+			project: ProjectConfig{APIKeyVar: "TEST_LOCO_KEY_MISSING"}, wantErr: errAuth},
+		{name: "Missing", project: ProjectConfig{Name: "web"}, wantErr: errAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source, err := tt.opts.projectKey(tt.project)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("projectKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("projectKey() = %q from %q, want %q from %q", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestKeyCanWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		want    bool
+		wantErr error
+	}{
+		{name: "Rejected", status: http.StatusUnprocessableEntity, want: true},
+		{name: "BadRequest", status: http.StatusBadRequest, want: true},
+		{name: "Created", status: http.StatusCreated, want: true},
+		{name: "ReadOnly", status: http.StatusForbidden},
+		{name: "Unauthorized", status: http.StatusUnauthorized},
+		{name: "Down", status: http.StatusBadGateway, wantErr: errNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("method = %s, want POST", r.Method)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			got, err := keyCanWrite("key", server.URL+"/api/tags")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("keyCanWrite() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("keyCanWrite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
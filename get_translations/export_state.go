@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// exportValidators are what loco sent with an export to tell later whether it changed, and a hash of the options the
+// export was written with
+type exportValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Options      string `json:"options,omitempty"`
+}
+
+// exportState is the --state-file: the validators of the exports downloaded by earlier runs, by export URL and
+// output directory. the exports are then requested conditionally and skipped when loco says they haven't changed,
+// unless they're written with different options this time.
+type exportState struct {
+	path    string
+	mu      sync.Mutex
+	Exports map[string]exportValidators `json:"exports"`
+	// the validators of the exports downloaded by this run, saved once the run succeeded
+	fetched map[string]exportValidators
+}
+
+// lastExports is nil without a state file, which makes every export download in full
+var lastExports *exportState
+
+func loadExportState(path string) (*exportState, error) {
+	s := &exportState{
+		path:    path,
+		Exports: make(map[string]exportValidators),
+		fetched: make(map[string]exportValidators),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Exports == nil {
+		s.Exports = make(map[string]exportValidators)
+	}
+	return s, nil
+}
+
+func exportStateKey(URL string, query url.Values, dir string) string {
+	return URL + "?" + query.Encode() + " " + filepath.Clean(dir)
+}
+
+// optionsHash fingerprints the options that change what's written from an export, e.g. --min-completion or the
+// android modules
+func optionsHash(opts interface{}) string {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		// can't happen with the options structs; an empty hash just never matches a saved one
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// fetch downloads an export that is written into dir with opts. when it was downloaded before, it's only downloaded
+// if it changed; otherwise the export is recorded as unchanged and errNotModified returned, and the caller has
+// nothing to do. the validators are only used while dir exists and opts are the same as last time, so a deleted
+// output or one written differently is downloaded again.
+func (s *exportState) fetch(apiKey, URL string, query url.Values, dir string, opts interface{}) (*http.Response, error) {
+	if s == nil {
+		return locoRequest(apiKey, URL, query)
+	}
+	key := exportStateKey(URL, query, dir)
+	options := optionsHash(opts)
+	s.mu.Lock()
+	last := s.Exports[key]
+	s.mu.Unlock()
+
+	header := http.Header{}
+	if isValidDir(dir) && last.Options == options {
+		if last.ETag != "" {
+			header.Set("If-None-Match", last.ETag)
+		}
+		if last.LastModified != "" {
+			header.Set("If-Modified-Since", last.LastModified)
+		}
+	}
+	resp, err := locoRequestWithHeaders(apiKey, URL, query, header)
+	if errors.Is(err, errNotModified) {
+		resp.Body.Close()
+		slog.Info("no changes", slog.String("dir", dir))
+		run.unchanged(dir)
+		return nil, err
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetched[key] = exportValidators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"),
+		Options: options}
+	return resp, nil
+}
+
+// save writes the validators of the exports this run downloaded into the state file. it's only called after a run
+// that succeeded, so an export that failed to be written is downloaded again next time.
+func (s *exportState) save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.fetched) == 0 {
+		return nil
+	}
+	for key, validators := range s.fetched {
+		if validators.ETag == "" && validators.LastModified == "" {
+			delete(s.Exports, key)
+		} else {
+			s.Exports[key] = validators
+		}
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0666)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
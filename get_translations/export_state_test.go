@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportStateFetch(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Tue, 01 Oct 2024 10:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = w.Write([]byte("export"))
+	}))
+	defer server.Close()
+	defer run.reset()
+
+	dir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	query := url.Values{"format": {"gettext"}}
+	savedOpts := exportOptions{Pseudo: []string{"en-XA"}}
+
+	tests := []struct {
+		name    string
+		dir     string
+		opts    exportOptions
+		saved   bool
+		wantErr error
+	}{
+		{name: "First", dir: dir, opts: savedOpts},
+		// saved only after a run that succeeded
+		{name: "NotSaved", dir: dir, opts: savedOpts},
+		{name: "Unchanged", dir: dir, opts: savedOpts, saved: true, wantErr: errNotModified},
+		{name: "OutputDeleted", dir: filepath.Join(dir, "missing"), opts: savedOpts, saved: true},
+		// the same export written differently
+		{name: "OptionsChanged", dir: dir, opts: exportOptions{Pseudo: []string{"en-XA"}, MinCompletion: 80},
+			saved: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run.reset()
+			s, err := loadExportState(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.saved {
+				s.Exports[exportStateKey(server.URL, query, tt.dir)] = exportValidators{ETag: etag,
+					Options: optionsHash(savedOpts)}
+			}
+			resp, err := s.fetch("key", server.URL, query, tt.dir, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fetch() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if code := run.finish("get_translations po", nil); code != exitOK || run.Status != runUnchanged {
+					t.Errorf("finish() = %d with status %s, want %d with %s", code, run.Status, exitOK, runUnchanged)
+				}
+				// the json summary says "no changes" too, for scripts that look for it on stdout
+				if run.Message != noChanges {
+					t.Errorf("summary message = %q, want %q", run.Message, noChanges)
+				}
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != "export" {
+				t.Errorf("body = %q, want export", body)
+			}
+			want := exportValidators{ETag: etag, LastModified: lastModified, Options: optionsHash(tt.opts)}
+			if got := s.fetched[exportStateKey(server.URL, query, tt.dir)]; got != want {
+				t.Errorf("fetched = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestExportStateSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := loadExportState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Exports["old"] = exportValidators{ETag: `"old"`}
+	s.Exports["gone"] = exportValidators{ETag: `"gone"`}
+	s.fetched["new"] = exportValidators{LastModified: "Tue, 01 Oct 2024 10:00:00 GMT"}
+	// an export that no longer has validators
+	s.fetched["gone"] = exportValidators{}
+	if err = s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadExportState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]exportValidators{
+		"old": {ETag: `"old"`},
+		"new": {LastModified: "Tue, 01 Oct 2024 10:00:00 GMT"},
+	}
+	if len(loaded.Exports) != len(want) {
+		t.Fatalf("exports = %v, want %v", loaded.Exports, want)
+	}
+	for key, v := range want {
+		if loaded.Exports[key] != v {
+			t.Errorf("exports[%s] = %v, want %v", key, loaded.Exports[key], v)
+		}
+	}
+
+	var none *exportState
+	if err = none.save(); err != nil {
+		t.Errorf("save() without a state file = %v", err)
+	}
+}
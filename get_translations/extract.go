@@ -0,0 +1,397 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/razor-1/deploy-utils/translations"
+)
+
+// extractedKey is a message ID found in source code, with the places it was found
+type extractedKey struct {
+	ID         string
+	References []string
+}
+
+// gettext style functions and the index of their message ID argument
+var gettextFuncs = map[string]int{
+	"gettext":   0,
+	"Gettext":   0,
+	"ngettext":  0,
+	"NGettext":  0,
+	"pgettext":  1,
+	"PGettext":  1,
+	"npgettext": 1,
+	"T":         0,
+}
+
+// i18next t('key') and t("key") calls. template literals are skipped since they are usually built at runtime.
+var i18nextCall = regexp.MustCompile(`(?:^|[^\w.$])(?:i18n(?:ext)?\.)?t\(\s*(?:'((?:[^'\\\n]|\\.)*)'|"((?:[^"\\\n]|\\.)*)")`)
+
+// android resource references in kotlin and java. the names are the asset IDs as the android export writes them.
+var androidStringRef = regexp.MustCompile(`\bR\.(?:string|plurals)\.(\w+)`)
+
+// String(localized: "key") and NSLocalizedString("key", ...) in swift
+var swiftLocalized = regexp.MustCompile(`\b(?:String\(\s*localized:|NSLocalizedString\()\s*"((?:[^"\\\n]|\\.)*)"`)
+
+var (
+	jsExtensions     = []string{".js", ".jsx", ".mjs", ".ts", ".tsx", ".vue"}
+	kotlinExtensions = []string{".kt", ".kts", ".java"}
+	swiftExtensions  = []string{".swift"}
+)
+
+// directories that never have our own source in them
+var skipDirs = []string{"node_modules", "vendor", "dist", "build", "testdata"}
+
+// keyCollector gathers message IDs from several sources
+type keyCollector struct {
+	keys map[string]*extractedKey
+}
+
+func newKeyCollector() *keyCollector {
+	return &keyCollector{keys: make(map[string]*extractedKey)}
+}
+
+func (c *keyCollector) add(id, reference string) {
+	if id == "" {
+		return
+	}
+	key, ok := c.keys[id]
+	if !ok {
+		key = &extractedKey{ID: id}
+		c.keys[id] = key
+	}
+	if reference != "" {
+		key.References = append(key.References, reference)
+	}
+}
+
+func (c *keyCollector) sorted() []extractedKey {
+	ids := make([]string, 0, len(c.keys))
+	for id := range c.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	keys := make([]extractedKey, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, *c.keys[id])
+	}
+	return keys
+}
+
+// walkSource calls fn for every file under root with one of the extensions, skipping dependency, output and hidden
+// directories
+func walkSource(root string, extensions []string, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || slices.Contains(skipDirs, name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(extensions, filepath.Ext(path)) {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// assetConstants reads the constants in a generated asset_ids.go file, mapping their names to their asset IDs
+func assetConstants(path string) (map[string]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	constants := make(map[string]string)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, name := range valueSpec.Names {
+				if i >= len(valueSpec.Values) {
+					continue
+				}
+				if value, ok := stringLiteral(valueSpec.Values[i]); ok {
+					constants[name.Name] = value
+				}
+			}
+		}
+	}
+	return constants, nil
+}
+
+// extractGoKeys finds the message IDs used in the go files under root: references to the asset constants, e.g.
+// loc.Get(locale.ImportDropHere_Filename, args), and string literals passed to gettext style functions.
+// assetsFile is skipped, since every constant is declared there.
+func extractGoKeys(c *keyCollector, root string, constants map[string]string, assetsFile string) error {
+	absAssets := ""
+	if assetsFile != "" {
+		absAssets, _ = filepath.Abs(assetsFile)
+	}
+	// the files by directory, since the constants are told apart from other names by type checking each package
+	dirs := make(map[string][]string)
+	err := walkSource(root, []string{".go"}, func(path string) error {
+		dir := filepath.Dir(path)
+		dirs[dir] = append(dirs[dir], path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	dirNames := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		dirNames = append(dirNames, dir)
+	}
+	sort.Strings(dirNames)
+
+	fset := token.NewFileSet()
+	var assetsPkg *types.Package
+	if absAssets != "" {
+		assetsPkg, err = checkAssetsPackage(fset, absAssets)
+		if err != nil {
+			return err
+		}
+	}
+	for _, dir := range dirNames {
+		err = extractGoPackageKeys(c, fset, dirs[dir], constants, absAssets, assetsPkg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAssetsPackage type checks the generated asset constants on their own, for the packages that import them
+func checkAssetsPackage(fset *token.FileSet, absAssets string) (*types.Package, error) {
+	file, err := parser.ParseFile(fset, absAssets, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{Importer: emptyImporter{}, Error: func(error) {}}
+	pkg, _ := conf.Check(assetsImportPath(absAssets), fset, []*ast.File{file}, nil)
+	return pkg, nil
+}
+
+// assetsImportPath stands in for the import path of the constants' package; imports are matched by its last element
+func assetsImportPath(absAssets string) string {
+	return filepath.Base(filepath.Dir(absAssets))
+}
+
+// extractGoPackageKeys adds the message IDs used in the files of one directory. the files are type checked so that
+// only the names that resolve to the constants in absAssets count, not a variable or field that shares a name with
+// one. other packages aren't loaded, so the errors from using them are ignored.
+func extractGoPackageKeys(c *keyCollector, fset *token.FileSet, paths []string, constants map[string]string,
+	absAssets string, assetsPkg *types.Package) error {
+	byPackage := make(map[string][]*ast.File)
+	extracted := make(map[*ast.File]bool)
+	for _, filePath := range paths {
+		absPath, _ := filepath.Abs(filePath)
+		if absAssets != "" && absPath == absAssets {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filePath, nil, 0)
+		if err != nil {
+			return err
+		}
+		byPackage[file.Name.Name] = append(byPackage[file.Name.Name], file)
+		extracted[file] = true
+	}
+	if absAssets != "" && len(paths) > 0 && filepath.Dir(absAssets) == absDir(paths[0]) {
+		// the constants are used unqualified in their own package, so it's checked along with them
+		file, err := parser.ParseFile(fset, absAssets, nil, 0)
+		if err != nil {
+			return err
+		}
+		byPackage[file.Name.Name] = append(byPackage[file.Name.Name], file)
+	}
+	names := make([]string, 0, len(byPackage))
+	for name := range byPackage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	importer := assetsImporter{path: assetsImportPath(absAssets), pkg: assetsPkg}
+	for _, name := range names {
+		files := byPackage[name]
+		info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+		conf := types.Config{Importer: importer, Error: func(error) {}, FakeImportC: true}
+		_, _ = conf.Check(name, fset, files, info)
+
+		for _, file := range files {
+			if !extracted[file] {
+				continue
+			}
+			extractGoFileKeys(c, fset, file, info, constants, absAssets)
+		}
+	}
+	return nil
+}
+
+// extractGoFileKeys adds the constants file uses and the literal IDs it passes to gettext style functions
+func extractGoFileKeys(c *keyCollector, fset *token.FileSet, file *ast.File, info *types.Info,
+	constants map[string]string, absAssets string) {
+	reference := func(n ast.Node) string {
+		pos := fset.Position(n.Pos())
+		return fmt.Sprintf("%s:%d", filepath.ToSlash(pos.Filename), pos.Line)
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Ident:
+			if id, ok := assetConstant(fset, info.Uses[n], constants, absAssets); ok {
+				c.add(id, reference(n))
+			}
+		case *ast.CallExpr:
+			if index, ok := gettextFuncs[funcName(n.Fun)]; ok && index < len(n.Args) {
+				if id, ok := stringLiteral(n.Args[index]); ok {
+					c.add(id, reference(n.Args[index]))
+				}
+			}
+		}
+		return true
+	})
+}
+
+// assetConstant returns the asset ID of the constant obj, when it's one of the constants declared in absAssets
+func assetConstant(fset *token.FileSet, obj types.Object, constants map[string]string, absAssets string) (string,
+	bool) {
+	constant, ok := obj.(*types.Const)
+	if !ok || absAssets == "" || fset.Position(constant.Pos()).Filename != absAssets {
+		return "", false
+	}
+	id, ok := constants[constant.Name()]
+	return id, ok
+}
+
+// assetsImporter gives the packages being checked the constants' package for the imports that end in its
+// directory's name, and empty packages for the rest
+type assetsImporter struct {
+	path string
+	pkg  *types.Package
+}
+
+func (i assetsImporter) Import(importPath string) (*types.Package, error) {
+	if i.pkg != nil && path.Base(importPath) == i.path {
+		return i.pkg, nil
+	}
+	return emptyImporter{}.Import(importPath)
+}
+
+// emptyImporter stands in an empty package for every import, so that type checking doesn't need the dependencies
+type emptyImporter struct{}
+
+func (emptyImporter) Import(importPath string) (*types.Package, error) {
+	pkg := types.NewPackage(importPath, path.Base(importPath))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+func absDir(filePath string) string {
+	absPath, _ := filepath.Abs(filePath)
+	return filepath.Dir(absPath)
+}
+
+func funcName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	}
+	return ""
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// extractJSKeys finds the keys in i18next t() calls in the javascript and typescript files under root
+func extractJSKeys(c *keyCollector, root string) error {
+	return extractPatternKeys(c, root, jsExtensions, i18nextCall)
+}
+
+// extractKotlinKeys finds the R.string and R.plurals resource names used in the kotlin and java files under root
+func extractKotlinKeys(c *keyCollector, root string) error {
+	return extractPatternKeys(c, root, kotlinExtensions, androidStringRef)
+}
+
+// extractSwiftKeys finds the keys of localized strings in the swift files under root
+func extractSwiftKeys(c *keyCollector, root string) error {
+	return extractPatternKeys(c, root, swiftExtensions, swiftLocalized)
+}
+
+// extractPatternKeys adds the key captured by pattern on each line of the files. patterns with alternatives for
+// different quotes capture the key in whichever group matched.
+func extractPatternKeys(c *keyCollector, root string, extensions []string, pattern *regexp.Regexp) error {
+	return walkSource(root, extensions, func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+				key := strings.Join(match[1:], "")
+				c.add(unescapeQuoted(key), fmt.Sprintf("%s:%d", filepath.ToSlash(path), i+1))
+			}
+		}
+		return nil
+	})
+}
+
+// unescapeQuoted resolves the backslash escapes that matter in a key: quotes and backslashes
+func unescapeQuoted(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// extractPOTKeys adds the msgids in a POT file
+func extractPOTKeys(c *keyCollector, path string) error {
+	catalog, err := translations.ParsePOFile(path)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(catalog.Messages))
+	for _, msg := range catalog.Messages {
+		ids = append(ids, msg.ID)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c.add(id, filepath.ToSlash(path))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func keyIDs(keys []extractedKey) []string {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.ID)
+	}
+	return ids
+}
+
+func TestExtractKeys(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"locale/asset_ids.go": `package locale
+
+const (
+    ImportDropHere_Filename = "import.drop-here %(filename)s"
+    Unused = "unused"
+    Title = "title"
+)
+`,
+		"locale/locale.go": `package locale
+
+type entry struct{ Unused string }
+
+func describe() string {
+	e := entry{Unused: Title}
+	return e.Unused
+}
+`,
+		"api/handler.go": `package api
+
+import (
+	"net/http"
+
+	"example.com/app/locale"
+)
+
+type form struct{ Unused string }
+
+func handler(loc *locale.Localizer) {
+	title := loc.Get(locale.ImportDropHere_Filename, nil)
+	// names that aren't the constants
+	Unused := "x"
+	f := form{Unused: Unused}
+	_ = f.Unused
+	msg := gettext("Saved")
+	other := pgettext("menu", "Open")
+	url := http.Get("https://example.com")
+}
+`,
+		"web/src/app.tsx": `const a = t('nav.home');
+const b = i18next.t("nav.about", {count: 2});
+const c = t('it\'s');
+const d = format('not.a.key');
+const e = t(` + "`dynamic.${x}`" + `);
+`,
+		"web/node_modules/lib/index.js": `t('dependency.key')`,
+		"messages.pot": `msgid ""
+msgstr ""
+
+msgid "From POT"
+msgstr ""
+`,
+	})
+
+	tests := []struct {
+		name string
+		opts pushOptions
+		want []string
+	}{
+		{
+			name: "Go",
+			opts: pushOptions{
+				GoDirs:     []string{filepath.Join(dir, "api"), filepath.Join(dir, "locale")},
+				AssetsFile: filepath.Join(dir, "locale", "asset_ids.go"),
+			},
+			want: []string{"Open", "Saved", "import.drop-here %(filename)s", "title"},
+		},
+		{
+			name: "JS",
+			opts: pushOptions{JSDirs: []string{filepath.Join(dir, "web")}},
+			want: []string{"it's", "nav.about", "nav.home"},
+		},
+		{
+			name: "POT",
+			opts: pushOptions{POTFiles: []string{filepath.Join(dir, "messages.pot")}},
+			want: []string{"From POT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := extractKeys(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keyIDs(keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingAssets(t *testing.T) {
+	keys := []extractedKey{
+		{ID: "a", References: []string{"a.go:1"}},
+		{ID: "b", References: []string{"b.go:1", "b.go:2"}},
+	}
+	missing := missingAssets(keys, []LocoAsset{{ID: "a"}})
+	if got := keyIDs(missing); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("missingAssets() = %v", got)
+	}
+	want := "from push\nUsed in: b.go:1, b.go:2"
+	if got := assetNotes(missing[0], "from push"); got != want {
+		t.Errorf("assetNotes() = %q, want %q", got, want)
+	}
+}
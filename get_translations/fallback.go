@@ -1,40 +1,125 @@
 package main
 
 import (
+	"bytes"
+	"embed"
 	"encoding/json"
 	"fmt"
+	"go/format"
+	"io"
 	"log/slog"
 	"net/url"
-	"slices"
+	"os"
 	"strings"
+	"text/template"
 
+	"github.com/razor-1/deploy-utils/translations"
 	"golang.org/x/text/language"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	locoLocales = "https://localise.biz/api/locales"
+
+	fallbackFormatText = "text"
+	fallbackFormatJSON = "json"
+	fallbackFormatYAML = "yaml"
+	fallbackFormatGo   = "go"
+	fallbackFormatTS   = "ts"
+	fallbackGoTplName  = "fallback_go.tpl"
+	fallbackTSTplName  = "fallback_ts.tpl"
 )
 
+// the go and ts templates are built in, so that the fallback command runs from anywhere
+//
+//go:embed fallback_go.tpl fallback_ts.tpl
+var fallbackTemplates embed.FS
+
 type LocoLocale struct {
-	Code   string `json:"code"`
-	Name   string `json:"name"`
-	Source bool   `json:"source"`
+	Code     string       `json:"code"`
+	Name     string       `json:"name"`
+	Source   bool         `json:"source"`
+	Plurals  LocoPlurals  `json:"plurals"`
+	Progress LocoProgress `json:"progress"`
 }
 
-func getFallbackLangs(apiKey string) error {
-	resp, err := locoRequest(apiKey, locoLocales, url.Values{})
+// LocoProgress is how much of the project loco has translated in a locale, counted in assets
+type LocoProgress struct {
+	Translated   int `json:"translated"`
+	Untranslated int `json:"untranslated"`
+	Flagged      int `json:"flagged"`
+}
+
+// Percent is the share of the assets that are translated
+func (p LocoProgress) Percent() float64 {
+	total := p.Translated + p.Untranslated
+	if total == 0 {
+		return 100
+	}
+	return float64(p.Translated) * 100 / float64(total)
+}
+
+// LocoPlurals are the plural rules of a locale. Forms are the CLDR categories, in the order loco keeps plural forms.
+type LocoPlurals struct {
+	Length   int      `json:"length"`
+	Equation string   `json:"equation"`
+	Forms    []string `json:"forms"`
+}
+
+type fallbackOptions struct {
+	// text, json, yaml, go or ts
+	Format string
+	// file to write to. stdout when empty
+	Output string
+	// package name for the go format
+	Package string
+	// distance or cldr
+	Strategy string
+}
+
+// fallbackChain is the list of locales to try, in order, when a string is missing for Locale
+type fallbackChain struct {
+	Locale    string
+	Fallbacks []string
+}
+
+func getFallbackLangs(apiKey string, opts fallbackOptions) error {
+	allLocales, err := getLocoLocales(apiKey)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	jd := json.NewDecoder(resp.Body)
-	var allLocales []LocoLocale
-	err = jd.Decode(&allLocales)
+	chains, err := fallbackChains(allLocales, opts.Strategy)
 	if err != nil {
-		slog.Error("error reading response", slog.Any("err", err))
 		return err
 	}
+	return outputFallbackChains(chains, opts)
+}
+
+// outputFallbackChains writes the chains to the output file, or stdout. they're rendered first, so that an unknown
+// format or a template error doesn't leave the output file empty.
+func outputFallbackChains(chains []fallbackChain, opts fallbackOptions) error {
+	var buf bytes.Buffer
+	err := writeFallbackChains(&buf, chains, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		_, err = buf.WriteTo(reportOut)
+		return err
+	}
+	return run.record(opts.Output, os.WriteFile(opts.Output, buf.Bytes(), 0666))
+}
+
+func fallbackChains(allLocales []LocoLocale, strategy string) ([]fallbackChain, error) {
+	matchFunc := translations.Fallbacks
+	switch strategy {
+	case "", fallbackStrategyDistance:
+	case fallbackStrategyCLDR:
+		matchFunc = cldrMatches
+	default:
+		return nil, fmt.Errorf("unknown fallback strategy: %s", strategy)
+	}
 
 	supported := make([]language.Tag, 0, len(allLocales))
 	var sourceTag language.Tag
@@ -54,44 +139,93 @@ func getFallbackLangs(apiKey string) error {
 	}
 
 	// we now have the supported list. let's go get the matcher list for each
+	chains := make([]fallbackChain, 0, len(supported))
 	for _, sup := range supported {
-		if tagsMatch(sup, sourceTag, false) {
+		if translations.TagsMatch(sup, sourceTag, false) {
 			continue
 		}
 
-		matches := allMatches(supported, sup, sourceTag)
-		fmt.Printf("%s: %s\n", sup.String(), strings.Join(tagsToString(matches), ", "))
+		matches := matchFunc(supported, sup, sourceTag)
+		chains = append(chains, fallbackChain{Locale: sup.String(), Fallbacks: tagsToString(matches)})
+	}
+	return chains, nil
+}
+
+func writeFallbackChains(out io.Writer, chains []fallbackChain, opts fallbackOptions) error {
+	chainMap := make(map[string][]string, len(chains))
+	for _, chain := range chains {
+		chainMap[chain.Locale] = chain.Fallbacks
 	}
 
-	return nil
+	switch opts.Format {
+	case "", fallbackFormatText:
+		for _, chain := range chains {
+			_, err := fmt.Fprintf(out, "%s: %s\n", chain.Locale, strings.Join(chain.Fallbacks, ", "))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case fallbackFormatJSON:
+		je := json.NewEncoder(out)
+		je.SetIndent("", "  ")
+		return je.Encode(chainMap)
+	case fallbackFormatYAML:
+		return yaml.NewEncoder(out).Encode(chainMap)
+	case fallbackFormatGo, fallbackFormatTS:
+		tplFile := fallbackGoTplName
+		if opts.Format == fallbackFormatTS {
+			tplFile = fallbackTSTplName
+		}
+		tmpl, err := template.New(tplFile).ParseFS(fallbackTemplates, tplFile)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		err = tmpl.Execute(&buf, struct {
+			Package string
+			Chains  []fallbackChain
+		}{Package: opts.Package, Chains: chains})
+		if err != nil {
+			return err
+		}
+		source := buf.Bytes()
+		if opts.Format == fallbackFormatGo {
+			// lines up the map the way gofmt does when the locales differ in length
+			source, err = format.Source(source)
+			if err != nil {
+				return fmt.Errorf("formatting the generated go: %w", err)
+			}
+		}
+		_, err = out.Write(source)
+		return err
+	}
+	return fmt.Errorf("unknown fallback format: %s", opts.Format)
 }
 
-func allMatches(supported []language.Tag, toMatch, source language.Tag) []language.Tag {
-	matched := make([]language.Tag, 0)
-
-	otherSupported := make([]language.Tag, len(supported))
-	copy(otherSupported, supported)
-	otherSupported = slices.DeleteFunc(otherSupported, func(tag language.Tag) bool {
-		return tagsMatch(tag, toMatch, false)
-	})
-	match := langMatch(otherSupported, toMatch)
-	matched = append(matched, match)
-	if tagsMatch(match, source, false) {
-		return matched
+// getLocoLocales returns all the locales in the project, including the source locale
+func getLocoLocales(apiKey string) (allLocales []LocoLocale, err error) {
+	resp, err := locoRequest(apiKey, locoLocales, url.Values{})
+	if err != nil {
+		return
 	}
+	defer resp.Body.Close()
 
-	nextSupported := make([]language.Tag, len(otherSupported))
-	copy(nextSupported, otherSupported)
-	nextSupported = slices.DeleteFunc(nextSupported, func(tag language.Tag) bool {
-		return tagsMatch(tag, match, false)
-	})
-	return append(matched, allMatches(nextSupported, toMatch, source)...)
+	jd := json.NewDecoder(resp.Body)
+	err = jd.Decode(&allLocales)
+	if err != nil {
+		slog.Error("error reading response", slog.Any("err", err))
+	}
+	return
 }
 
-func langMatch(supported []language.Tag, toMatch language.Tag) language.Tag {
-	matcher := language.NewMatcher(supported)
-	_, index, _ := matcher.Match(toMatch)
-	return supported[index]
+func sourceLocale(allLocales []LocoLocale) (LocoLocale, bool) {
+	for _, loc := range allLocales {
+		if loc.Source {
+			return loc, true
+		}
+	}
+	return LocoLocale{}, false
 }
 
 func tagsToString(tags []language.Tag) []string {
@@ -101,20 +235,3 @@ func tagsToString(tags []language.Tag) []string {
 	}
 	return tagStrings
 }
-
-func tagsMatch(t1, t2 language.Tag, baseOnly bool) bool {
-	if t1 == t2 {
-		return true
-	}
-	t1b, _ := t1.Base()
-	t2b, _ := t2.Base()
-	if t1b == t2b {
-		if baseOnly {
-			return true
-		}
-		t1r, _ := t1.Region()
-		t2r, _ := t2.Region()
-		return t1r == t2r
-	}
-	return false
-}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/razor-1/deploy-utils/translations"
+	"golang.org/x/text/language"
+)
+
+const (
+	fallbackStrategyDistance = "distance"
+	fallbackStrategyCLDR     = "cldr"
+)
+
+// the parentLocales from CLDR's supplemental data that matter for the languages we translate into. anything not in
+// here falls back by removing subtags.
+var cldrParentLocales = func() map[string]string {
+	parents := map[string]string{
+		"en-150":     "en-001",
+		"es-419":     "es",
+		"zh-Hant-MO": "zh-Hant-HK",
+	}
+	addRegions := func(base, parent, regions string) {
+		for _, region := range strings.Fields(regions) {
+			parents[base+"-"+region] = parent
+		}
+	}
+	addRegions("en", "en-001", "AG AI AU BB BM BS BW BZ CA CC CK CM CX CY DG DM ER FJ FK FM GB GD GG GH GI GM GY "+
+		"HK IE IL IM IN IO JE JM KE KI KN KY LC LR LS MG MO MS MT MU MV MW MY NA NF NG NR NU NZ PG PK PN PW RW "+
+		"SB SC SD SG SH SL SS SX SZ TC TK TO TT TV TZ UG VC VG VU WS ZA ZM ZW")
+	addRegions("en", "en-150", "AT BE CH DE DK FI NL SE SI")
+	addRegions("es", "es-419", "AR BO BR BZ CL CO CR CU DO EC GT HN MX NI PA PE PR PY SV US UY VE")
+	addRegions("pt", "pt-PT", "AO CH CV FR GQ GW LU MO MZ ST TL")
+	return parents
+}()
+
+// cldrMatches builds the fallback chain for toMatch by following the CLDR parent locales first, then adding the
+// distance based matches that are written in the same script. the source locale is always last.
+func cldrMatches(supported []language.Tag, toMatch, source language.Tag) []language.Tag {
+	matched := make([]language.Tag, 0)
+	add := func(tag language.Tag) {
+		if tag != toMatch && tag != source && !slices.ContainsFunc(matched, func(t language.Tag) bool { return t == tag }) {
+			matched = append(matched, tag)
+		}
+	}
+
+	for parent, ok := cldrParent(toMatch); ok; parent, ok = cldrParent(parent) {
+		for _, sup := range supported {
+			if maximizedKey(sup) == maximizedKey(parent) {
+				add(sup)
+			}
+		}
+	}
+
+	script, _ := toMatch.Script()
+	for _, match := range translations.Fallbacks(supported, toMatch, source) {
+		if matchScript, _ := match.Script(); matchScript == script {
+			add(match)
+		}
+	}
+	return append(matched, source)
+}
+
+// cldrParent returns the CLDR parent of tag, or false when the parent is root
+func cldrParent(tag language.Tag) (language.Tag, bool) {
+	base, _ := tag.Base()
+	script, scriptConf := tag.Script()
+	region, regionConf := tag.Region()
+	explicitScript := scriptConf == language.Exact
+	explicitRegion := regionConf == language.Exact
+
+	for _, key := range []string{tag.String(), composeKey(base, script, region, explicitRegion)} {
+		if parent, ok := cldrParentLocales[key]; ok {
+			return language.Make(parent), true
+		}
+	}
+
+	defaultScript, _ := language.Make(base.String()).Script()
+	switch {
+	case len(tag.Variants()) > 0:
+		parts := []string{base.String()}
+		if explicitScript {
+			parts = append(parts, script.String())
+		}
+		if explicitRegion {
+			parts = append(parts, region.String())
+		}
+		return language.Make(strings.Join(parts, "-")), true
+	case explicitRegion:
+		// keep the script when it isn't the default for the language, e.g. zh-TW goes to zh-Hant rather than zh
+		if script != defaultScript {
+			return language.Make(base.String() + "-" + script.String()), true
+		}
+		return language.Make(base.String()), true
+	case explicitScript && script == defaultScript:
+		return language.Make(base.String()), true
+	}
+	// a bare language, or a script that isn't the default for the language, e.g. sr-Latn
+	return language.Tag{}, false
+}
+
+func composeKey(base language.Base, script language.Script, region language.Region, withRegion bool) string {
+	key := base.String() + "-" + script.String()
+	if withRegion {
+		key += "-" + region.String()
+	}
+	return key
+}
+
+// maximizedKey identifies a tag by its likely language, script and region, so that e.g. pt and pt-BR or zh-Hant and
+// zh-TW are the same locale
+func maximizedKey(tag language.Tag) string {
+	base, _ := tag.Base()
+	script, _ := tag.Script()
+	region, _ := tag.Region()
+	key := composeKey(base, script, region, true)
+	for _, variant := range tag.Variants() {
+		key += "-" + variant.String()
+	}
+	return key
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/razor-1/deploy-utils/translations"
+	"golang.org/x/text/language"
+)
+
+func TestCLDRMatchesDifferFromDistance(t *testing.T) {
+	// the parent CLDR gives comes first, then the rest of the language in order; the distance matcher ranks the
+	// siblings by how close they are instead, or skips the parent altogether
+	tests := []struct {
+		locale    string
+		supported []string
+		expected  []string
+	}{
+		{
+			locale:    "es-MX",
+			supported: []string{"en-US", "es-ES", "es-US", "es-419", "es-MX"},
+			expected:  []string{"es-419", "es-ES", "es-US", "en-US"},
+		},
+		{
+			locale:    "pt-AO",
+			supported: []string{"en-US", "pt-BR", "pt-MZ", "pt-PT", "pt-AO"},
+			expected:  []string{"pt-PT", "pt-BR", "pt-MZ", "en-US"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			supported := make([]language.Tag, len(tt.supported))
+			for i, s := range tt.supported {
+				supported[i] = language.MustParse(s)
+			}
+			toMatch := language.MustParse(tt.locale)
+			result := tagsToString(cldrMatches(supported, toMatch, supported[0]))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("cldrMatches(%s) = %v, want %v", tt.locale, result, tt.expected)
+			}
+			distance := tagsToString(translations.Fallbacks(supported, toMatch, supported[0]))
+			if reflect.DeepEqual(distance, result) {
+				t.Errorf("cldrMatches(%s) = %v, the same as the distance matcher", tt.locale, result)
+			}
+		})
+	}
+}
+
+func TestCLDRMatchesParentLocales(t *testing.T) {
+	tests := []struct {
+		locale    string
+		supported []string
+		expected  []string
+	}{
+		{
+			locale:    "es-AR",
+			supported: []string{"en-US", "es", "es-MX"},
+			expected:  []string{"es", "es-MX", "en-US"},
+		},
+		{
+			locale:    "en-DE",
+			supported: []string{"en-US", "en-150", "en-001", "de-DE"},
+			expected:  []string{"en-150", "en-001", "en-US"},
+		},
+		{
+			locale:    "zh-TW",
+			supported: []string{"en-US", "zh-Hans", "zh-Hant", "zh-TW"},
+			expected:  []string{"zh-Hant", "en-US"},
+		},
+		{
+			locale:    "zh-Hant-MO",
+			supported: []string{"en-US", "zh-Hans", "zh-Hant-HK", "zh-Hant", "zh-Hant-MO"},
+			expected:  []string{"zh-Hant-HK", "zh-Hant", "en-US"},
+		},
+		{
+			locale:    "sr-Latn",
+			supported: []string{"en-US", "sr", "sr-Latn", "bs-Latn"},
+			expected:  []string{"en-US"},
+		},
+		{
+			locale:    "sr-Cyrl-RS",
+			supported: []string{"en-US", "sr-Latn", "sr", "sr-Cyrl-RS"},
+			expected:  []string{"sr", "en-US"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			supported := make([]language.Tag, len(tt.supported))
+			for i, s := range tt.supported {
+				supported[i] = language.MustParse(s)
+			}
+			result := tagsToString(cldrMatches(supported, language.MustParse(tt.locale), supported[0]))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("cldrMatches(%s) = %v, want %v", tt.locale, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFallbackChains(t *testing.T) {
+	chains := []fallbackChain{
+		{Locale: "es-MX", Fallbacks: []string{"es", "en-US"}},
+		{Locale: "pt-PT", Fallbacks: []string{"pt-BR", "en-US"}},
+		{Locale: "zh-Hant-TW", Fallbacks: []string{"zh-Hant", "en-US"}},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   fallbackFormatText,
+			expected: "es-MX: es, en-US\npt-PT: pt-BR, en-US\nzh-Hant-TW: zh-Hant, en-US\n",
+		},
+		{
+			format: fallbackFormatJSON,
+			expected: "{\n  \"es-MX\": [\n    \"es\",\n    \"en-US\"\n  ],\n  \"pt-PT\": [\n    \"pt-BR\",\n    \"en-US\"\n  ],\n" +
+				"  \"zh-Hant-TW\": [\n    \"zh-Hant\",\n    \"en-US\"\n  ]\n}\n",
+		},
+		{
+			format:   fallbackFormatYAML,
+			expected: "es-MX:\n- es\n- en-US\npt-PT:\n- pt-BR\n- en-US\nzh-Hant-TW:\n- zh-Hant\n- en-US\n",
+		},
+		{
+			format: fallbackFormatGo,
+			expected: `// Code generated by get_translations. DO NOT EDIT.
+
+package locale
+
+// Fallbacks lists the locales to try, in order, when a string is missing for a locale
+var Fallbacks = map[string][]string{
+	"es-MX":      {"es", "en-US"},
+	"pt-PT":      {"pt-BR", "en-US"},
+	"zh-Hant-TW": {"zh-Hant", "en-US"},
+}
+`,
+		},
+		{
+			format: fallbackFormatTS,
+			expected: `// Code generated by get_translations. DO NOT EDIT.
+
+// the locales to try, in order, when a string is missing for a locale
+export const fallbacks: Record<string, string[]> = {
+  "es-MX": ["es", "en-US"],
+  "pt-PT": ["pt-BR", "en-US"],
+  "zh-Hant-TW": ["zh-Hant", "en-US"],
+};
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeFallbackChains(&buf, chains, fallbackOptions{Format: tt.format, Package: "locale"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.expected)
+			}
+			if tt.format == fallbackFormatGo {
+				formatted, fmtErr := format.Source(buf.Bytes())
+				if fmtErr != nil || !bytes.Equal(formatted, buf.Bytes()) {
+					t.Errorf("generated go is not gofmt clean: %v", fmtErr)
+				}
+			}
+		})
+	}
+
+	if err := writeFallbackChains(&bytes.Buffer{}, chains, fallbackOptions{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestOutputFallbackChainsUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fallbacks.json")
+	if err := os.WriteFile(path, []byte("{}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	chains := []fallbackChain{{Locale: "es-MX", Fallbacks: []string{"es", "en-US"}}}
+	if err := outputFallbackChains(chains, fallbackOptions{Format: "xml", Output: path}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{}\n" {
+		t.Errorf("output file = %q, want it left alone", data)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	if err = outputFallbackChains(chains, fallbackOptions{Format: "xml", Output: missing}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if _, err = os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("output file created for an unknown format: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -15,6 +16,14 @@ const (
 	locoFilter   = "filter"
 )
 
+// exportOptions are the options shared by the exporters without their own options struct
+type exportOptions struct {
+	// locales less than this percent translated are left out. 0 disables.
+	MinCompletion float64
+	// pseudo-locales to generate from the source locale
+	Pseudo []string
+}
+
 func isValidDir(dir string) bool {
 	info, err := os.Stat(dir)
 	if err != nil {
@@ -23,7 +32,16 @@ func isValidDir(dir string) bool {
 	return info.IsDir()
 }
 
+// errNotModified is returned with the response when a conditional request gets a 304
+var errNotModified = errors.New("not modified")
+
 func locoRequest(apiKey, URL string, queryParams url.Values) (resp *http.Response, err error) {
+	return locoRequestWithHeaders(apiKey, URL, queryParams, nil)
+}
+
+// locoRequestWithHeaders is locoRequest with extra request headers, e.g. for conditional requests
+func locoRequestWithHeaders(apiKey, URL string, queryParams url.Values, header http.Header) (resp *http.Response,
+	err error) {
 	reqURL, err := url.Parse(URL)
 	if err != nil {
 		return nil, err
@@ -33,24 +51,64 @@ func locoRequest(apiKey, URL string, queryParams url.Values) (resp *http.Respons
 	client := http.DefaultClient
 	client.Timeout = 30 * time.Second
 	req, err := http.NewRequest(http.MethodGet, reqURL.String(), nil)
-	slog.Info(reqURL.String())
+	slog.Debug("loco request", slog.String("method", http.MethodGet), slog.String("url", redactURL(reqURL)))
 	if err != nil {
 		return
 	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 	req.Header.Add(authHeader, fmt.Sprintf("Loco %s", apiKey))
 	resp, err = client.Do(req)
 	if err != nil {
+		err = redactURLError(err, reqURL)
 		slog.Error("error fetching", slog.Any("err", err))
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", errNetwork, err)
 	}
+	resp.Body = countingBody{resp.Body}
 
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, errNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return resp, fmt.Errorf("status not OK: is %d", resp.StatusCode)
+		return resp, statusError(resp.StatusCode)
 	}
 	return
 }
 
+// redactURLError redacts the request URL that net/http puts in its errors
+func redactURLError(err error, reqURL *url.URL) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(reqURL)
+	}
+	return err
+}
+
+// statusError classifies an unexpected status from loco, so the exit code can tell a bad API key from loco being
+// down
+func statusError(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return fmt.Errorf("%w: status not OK: is %d", errAuth, status)
+	case status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: status not OK: is %d", errNetwork, status)
+	}
+	return fmt.Errorf("status not OK: is %d", status)
+}
+
 func locoWrite(apiKey, URL, method string, body []byte) (resp *http.Response, err error) {
+	return locoSend(apiKey, URL, method, "", body)
+}
+
+// locoWriteForm sends form parameters, which is how loco takes the fields when creating assets and tags
+func locoWriteForm(apiKey, URL, method string, form url.Values) (resp *http.Response, err error) {
+	return locoSend(apiKey, URL, method, "application/x-www-form-urlencoded", []byte(form.Encode()))
+}
+
+func locoSend(apiKey, URL, method, contentType string, body []byte) (resp *http.Response, err error) {
 	reqURL, err := url.Parse(URL)
 	if err != nil {
 		return
@@ -62,16 +120,22 @@ func locoWrite(apiKey, URL, method string, body []byte) (resp *http.Response, er
 	if err != nil {
 		return
 	}
+	slog.Debug("loco request", slog.String("method", method), slog.String("url", redactURL(reqURL)))
 	req.Header.Add(authHeader, fmt.Sprintf("Loco %s", apiKey))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	resp, err = client.Do(req)
 	if err != nil {
+		err = redactURLError(err, reqURL)
 		slog.Error("error performing", slog.Any("err", err))
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", errNetwork, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return resp, fmt.Errorf("status not OK: is %d", resp.StatusCode)
+	// creating things returns 201
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return resp, statusError(resp.StatusCode)
 	}
 	return
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -20,7 +21,9 @@ const (
 	locoYamlFormat    = "simple"
 )
 
-func getHugoYaml(apiKey, baseDir, filter string) error {
+// getHugoYaml writes a yaml file per locale for go-i18n in hugo. loco names the files in the archive after the project
+// apiKey belongs to, which is usually project.
+func getHugoYaml(apiKey, project, baseDir, filter string, opts exportOptions) error {
 	qp := url.Values{}
 	qp.Add("format", locoYamlFormat)
 	qp.Add("fallback", locoFallback)
@@ -28,11 +31,19 @@ func getHugoYaml(apiKey, baseDir, filter string) error {
 	if filter != "" {
 		qp.Add("filter", filter)
 	}
-	resp, err := locoRequest(apiKey, locoYamlExportURL, qp)
+	resp, err := lastExports.fetch(apiKey, locoYamlExportURL, qp, baseDir, opts)
+	if errors.Is(err, errNotModified) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	gate, err := newCompletionGate(apiKey, filter, opts.MinCompletion)
+	if err != nil {
+		return err
+	}
+	defer gate.report()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -46,15 +57,25 @@ func getHugoYaml(apiKey, baseDir, filter string) error {
 		return nil
 	}
 
-	yamlData := make(map[string][]byte)
+	yamlFiles := make([]*zip.File, 0, len(zipReader.File))
+	names := make([]string, 0, len(zipReader.File))
 	for _, zipFile := range zipReader.File {
 		_, zipName := filepath.Split(zipFile.Name)
 		ext := filepath.Ext(zipName)
 		if ext != ".yml" {
 			continue
 		}
+		yamlFiles = append(yamlFiles, zipFile)
+		names = append(names, strings.TrimSuffix(zipName, ext))
+	}
+	prefix := archivePrefix(names, project)
 
-		localeCode := strings.ToLower(strings.TrimPrefix(strings.TrimSuffix(zipName, ext), locoProject+"-"))
+	yamlData := make(map[string][]byte)
+	for i, zipFile := range yamlFiles {
+		localeCode := strings.ToLower(strings.TrimPrefix(names[i], prefix))
+		if !gate.allows(localeCode) {
+			continue
+		}
 
 		f, err := zipFile.Open()
 		if err != nil {
@@ -69,19 +90,53 @@ func getHugoYaml(apiKey, baseDir, filter string) error {
 		}
 		f.Close()
 	}
+	writeHugoYamlFiles(baseDir, yamlData)
+	return nil
+}
+
+// archivePrefix is what loco put before the locale in the names of the files in an export archive, e.g. hourglass-
+// in hourglass-de_DE. it's the part of the names they all have, up to a dash, since loco's slug for the project can
+// differ from its name in the config. with only one file, there's nothing to compare, so it's project's when the name
+// starts with that.
+func archivePrefix(names []string, project string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	if len(names) == 1 && strings.HasPrefix(names[0], project+"-") {
+		return project + "-"
+	}
+	common := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	return common[:strings.LastIndex(common, "-")+1]
+}
 
+// writeHugoYamlFiles writes the yaml of each locale into baseDir, named after the language alone when the locale is
+// the only one with that language. a file that fails is recorded in the run summary and the rest are still written.
+func writeHugoYamlFiles(baseDir string, yamlData map[string][]byte) {
 	for localeCode, data := range yamlData {
 		filename := localeCode
-		lang := language.MustParse(localeCode)
+		lang, err := language.Parse(localeCode)
+		if err != nil {
+			outPath := fmt.Sprintf("%s.yaml", filepath.Join(baseDir, filename))
+			slog.Error("unknown locale in the export", slog.String("locale", localeCode), slog.Any("err", err))
+			run.failed(outPath, fmt.Errorf("unknown locale %s: %w", localeCode, err))
+			continue
+		}
 		baseLang, _ := lang.Base()
 		if localesWithBase(yamlData, baseLang) == 1 {
 			filename = baseLang.String()
 		}
 
-		outFile, err := os.Create(fmt.Sprintf("%s.yaml", filepath.Join(baseDir, filename)))
+		outPath := fmt.Sprintf("%s.yaml", filepath.Join(baseDir, filename))
+		outFile, err := os.Create(outPath)
 		if err != nil {
 			slog.Error("error creating output file",
 				slog.String("file", filename), slog.Any("err", err))
+			run.failed(outPath, err)
 			continue
 		}
 		if outFile == nil {
@@ -94,18 +149,18 @@ func getHugoYaml(apiKey, baseDir, filter string) error {
 		if err != nil {
 			slog.Error("error unmarshalling yaml",
 				slog.String("file", filename), slog.Any("err", err))
+			run.failed(outPath, err)
 			outFile.Close()
 			continue
 		}
 
-		err = writeYamlFile(yamlMap, outFile)
+		err = run.record(outPath, writeYamlFile(yamlMap, outFile))
 		if err != nil {
 			slog.Error("error writing output file",
 				slog.String("file", filename), slog.Any("err", err))
 		}
 		outFile.Close()
 	}
-	return nil
 }
 
 type go18nFormat struct {
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchivePrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		project string
+		want    string
+	}{
+		{name: "Project", names: []string{"hourglass-de_DE", "hourglass-fr_FR"}, project: "hourglass", want: "hourglass-"},
+		// the name in the config isn't loco's slug
+		{name: "ConfiguredName", names: []string{"web-app-de_DE", "web-app-de_AT", "web-app-fr_FR"}, project: "web",
+			want: "web-app-"},
+		{name: "SameLanguage", names: []string{"web-app-de_DE", "web-app-de_AT"}, project: "site", want: "web-app-"},
+		{name: "OneFile", names: []string{"hourglass-de_DE"}, project: "hourglass", want: "hourglass-"},
+		{name: "OneFileOtherName", names: []string{"web-app-de_DE"}, project: "site", want: "web-app-"},
+		{name: "None", project: "hourglass", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := archivePrefix(tt.names, tt.project); got != tt.want {
+				t.Errorf("archivePrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHugoYamlFiles(t *testing.T) {
+	defer run.reset()
+	run.reset()
+	dir := t.TempDir()
+	writeHugoYamlFiles(dir, map[string][]byte{
+		"de_de":         []byte("hello: Hallo\n"),
+		"name-de_de-xx": []byte("hello: Hallo\n"),
+	})
+
+	if _, err := os.Stat(filepath.Join(dir, "de.yaml")); err != nil {
+		t.Errorf("de.yaml wasn't written: %v", err)
+	}
+	failed := 0
+	for _, f := range run.Files {
+		if f.Status == fileFailed {
+			failed++
+			if f.Path != filepath.Join(dir, "name-de_de-xx.yaml") {
+				t.Errorf("failed file = %s", f.Path)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("failed files = %d, want 1: %+v", failed, run.Files)
+	}
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"golang.org/x/text/language"
 )
@@ -14,11 +17,19 @@ import (
 const (
 	locoJsonExportURL = locoBaseURL + "/export/all.json"
 	locoI18NextFormat = "i18next4"
-	locoProject       = "hourglass"
 )
 
-// retrieve loco assets in i18next format and write each locale's data to a separate json file
-func getI18Next(apiKey, dir, filter string) error {
+// retrieve loco assets in i18next format and write each locale's data to a separate json file, or with namespaces
+// to <locale>/<namespace>.json. the export is for project, the project apiKey belongs to.
+func getI18Next(apiKey, project, dir, filter string, opts exportOptions, nsOpts i18nextOptions) error {
+	err := validatePseudoLocales(opts.Pseudo)
+	if err != nil {
+		return err
+	}
+	err = nsOpts.validate()
+	if err != nil {
+		return err
+	}
 	qp := url.Values{}
 	qp.Add("format", locoI18NextFormat)
 	qp.Add("fallback", locoFallback)
@@ -27,11 +38,26 @@ func getI18Next(apiKey, dir, filter string) error {
 	if filter != "" {
 		qp.Add(locoFilter, filter)
 	}
-	resp, err := locoRequest(apiKey, locoJsonExportURL, qp)
+	resp, err := lastExports.fetch(apiKey, locoJsonExportURL, qp, dir, []interface{}{opts, nsOpts})
+	if errors.Is(err, errNotModified) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	var assetTags map[string][]string
+	if nsOpts.Namespaces == i18nextNamespaceTag {
+		assetTags, err = getAssetTags(apiKey, filter)
+		if err != nil {
+			return err
+		}
+	}
+	gate, err := newCompletionGate(apiKey, filter, opts.MinCompletion)
+	if err != nil {
+		return err
+	}
+	defer gate.report()
 
 	localeCodes := make(map[string]map[string]interface{})
 	jd := json.NewDecoder(resp.Body)
@@ -40,19 +66,47 @@ func getI18Next(apiKey, dir, filter string) error {
 		return err
 	}
 
+	if nsOpts.ICU {
+		allLocales, err := getLocoLocales(apiKey)
+		if err != nil {
+			return err
+		}
+		source, ok := sourceLocale(allLocales)
+		if !ok {
+			return fmt.Errorf("no source locale in loco")
+		}
+		err = checkI18NextICU(localeCodes, source.Code, gate)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(opts.Pseudo) > 0 {
+		err = addPseudoI18Next(apiKey, localeCodes, opts.Pseudo)
+		if err != nil {
+			return err
+		}
+	}
+
 	for locale, projects := range localeCodes {
-		for project, data := range projects {
-			if project != locoProject {
-				return fmt.Errorf("got unexpected project in i18next response from loco: %s", project)
+		if !gate.allows(locale) {
+			continue
+		}
+		for exportProject, data := range projects {
+			if len(projects) > 1 && !strings.EqualFold(exportProject, project) {
+				return fmt.Errorf("got unexpected project in i18next response from loco: %s", exportProject)
 			}
 			langFile := locales[locale]
 			if langFile == "" {
 				langFile = locale
-				fmt.Printf("could not find locale mapping for %s. using %s\n", locale, langFile)
+				fmt.Fprintf(reportOut, "could not find locale mapping for %s. using %s\n", locale, langFile)
 			}
 
-			fileName := filepath.Join(dir, fmt.Sprintf("%s.json", langFile))
-			err = writeToFile(fileName, data)
+			files, err := i18nextFiles(data, nsOpts, assetTags)
+			if err != nil {
+				return fmt.Errorf("%s: %w", locale, err)
+			}
+			err = writeI18NextFiles(dir, langFile, files)
 			if err != nil {
 				return err
 			}
@@ -65,8 +119,7 @@ func getI18Next(apiKey, dir, filter string) error {
 				// go's language parsing ends up with a different code than we expect. copy the file out so that we have both.
 				slog.Info("mismatch for code", slog.String("langFile", langFile),
 					slog.String("string", l.String()))
-				fileName = filepath.Join(dir, fmt.Sprintf("%s.json", l.String()))
-				err = writeToFile(fileName, data)
+				err = writeI18NextFiles(dir, l.String(), files)
 				if err != nil {
 					return err
 				}
@@ -77,6 +130,93 @@ func getI18Next(apiKey, dir, filter string) error {
 	return nil
 }
 
+// checkI18NextICU fails the export when any locale that would be written has malformed ICU messages. the arguments
+// are checked against the source locale in the export. loco has turned the placeholders into i18next's {{name}} for
+// the export, so they're read as the ICU arguments they were.
+func checkI18NextICU(localeCodes map[string]map[string]interface{}, sourceCode string, gate *completionGate) error {
+	texts := i18nextTexts(localeCodes)
+	for locale, messages := range texts {
+		if locale != sourceCode && !gate.allows(locale) {
+			delete(texts, locale)
+			continue
+		}
+		for key, text := range messages {
+			messages[key] = i18nextToICU(text)
+		}
+	}
+	issues := lintTexts(texts, sourceCode, map[string]lintRule{lintRuleICU: icuProblems})
+	if len(issues) > 0 {
+		writeLintIssues(reportOut, issues)
+		return fmt.Errorf("%w: %d ICU problems in the export", errValidation, len(issues))
+	}
+	return nil
+}
+
+// i18nextInterpolation is an i18next placeholder: {{name}}, {{name, format}} or the unescaped {{- name}}
+var i18nextInterpolation = regexp.MustCompile(`\{\{-?\s*([^{},\s]+)\s*(?:,[^{}]*)?\}\}`)
+
+// i18nextToICU turns the i18next placeholders in text into the ICU arguments {name}
+func i18nextToICU(text string) string {
+	return i18nextInterpolation.ReplaceAllString(text, "{$1}")
+}
+
+// addPseudoI18Next adds the pseudo-locales to the export, generated from the source locale
+func addPseudoI18Next(apiKey string, localeCodes map[string]map[string]interface{}, pseudo []string) error {
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco")
+	}
+	projects, ok := localeCodes[source.Code]
+	if !ok {
+		return fmt.Errorf("source locale %s is not in the export", source.Code)
+	}
+	for _, locale := range pseudo {
+		pseudoProjects := make(map[string]interface{}, len(projects))
+		for project, data := range projects {
+			pseudoProjects[project] = pseudolocalizeValues(locale, data)
+		}
+		localeCodes[locale] = pseudoProjects
+	}
+	return nil
+}
+
+// writeI18NextFiles writes a locale's files from i18nextFiles: <locale>.json, or <locale>/<namespace>.json
+func writeI18NextFiles(dir, langFile string, files map[string]interface{}) error {
+	for ns, data := range files {
+		fileName := filepath.Join(dir, fmt.Sprintf("%s.json", langFile))
+		if ns != "" {
+			nsDir := filepath.Join(dir, langFile)
+			err := os.MkdirAll(nsDir, 0777)
+			if err != nil {
+				return err
+			}
+			fileName = filepath.Join(nsDir, fmt.Sprintf("%s.json", ns))
+		}
+		err := run.record(fileName, writeToFile(fileName, data))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getAssetTags maps the asset IDs to their tags
+func getAssetTags(apiKey, filter string) (map[string][]string, error) {
+	assets, err := getAssets(apiKey, filter)
+	if err != nil {
+		return nil, err
+	}
+	assetTags := make(map[string][]string, len(assets))
+	for _, asset := range assets {
+		assetTags[asset.ID] = asset.Tags
+	}
+	return assetTags, nil
+}
+
 func writeToFile(path string, data interface{}) error {
 	outFile, err := os.Create(path)
 	if err != nil {
@@ -91,11 +231,15 @@ func writeToFile(path string, data interface{}) error {
 	return nil
 }
 
-// count how many keys in localeCodes have the supplied base
+// count how many keys in localeCodes have the supplied base. keys that aren't locales aren't counted.
 func localesWithBase[V any](localeCodes map[string]V, base language.Base) int {
 	count := 0
 	for locale := range localeCodes {
-		baseLang, _ := language.MustParse(locale).Base()
+		tag, err := language.Parse(locale)
+		if err != nil {
+			continue
+		}
+		baseLang, _ := tag.Base()
 		if baseLang == base {
 			count++
 		}
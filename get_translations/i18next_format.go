@@ -21,6 +21,8 @@ type LocoTranslationBase struct {
 type LocoTranslation struct {
 	LocoTranslationBase
 	Locale LocoLocale `json:"locale"`
+	// marked as needing attention in loco
+	Flagged bool `json:"flagged"`
 }
 
 type LocoAssetPrintf struct {
@@ -79,7 +81,7 @@ func i18nextConvertFormat(apiKey, assetID, formatKey string) error {
 		} else {
 			updateCount++
 			if strings.HasPrefix(translation.Locale.Code, "ca") {
-				slog.Info("ca translation debug", transURL,
+				slog.Debug("ca translation debug", slog.String("url", transURL),
 					slog.String("localeCode", translation.Locale.Code),
 					slog.String("localeName", translation.Locale.Name),
 				)
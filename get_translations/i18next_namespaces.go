@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	i18nextNamespacePrefix = "prefix"
+	i18nextNamespaceTag    = "tag"
+	// the namespace i18next loads when none is configured
+	i18nextDefaultNamespace = "translation"
+	i18nextKeySeparator     = "."
+)
+
+// i18next4 plural keys are the asset ID with the plural category appended
+var i18nextPluralSuffix = regexp.MustCompile(`_(zero|one|two|few|many|other)$`)
+
+type i18nextOptions struct {
+	// split each locale into a file per namespace, by key prefix or by loco tag. one file per locale when empty
+	Namespaces string
+	// with tag namespaces, the tags that are namespaces. every tag when empty
+	NamespaceTags []string
+	// the namespace of the keys without a prefix or namespace tag
+	DefaultNamespace string
+	// nest dotted keys into objects
+	Nest bool
+	// fail when a translation isn't valid ICU MessageFormat
+	ICU bool
+}
+
+func (o i18nextOptions) validate() error {
+	switch o.Namespaces {
+	case "", i18nextNamespacePrefix, i18nextNamespaceTag:
+	default:
+		return fmt.Errorf("unknown namespace mode %s: use %s or %s", o.Namespaces, i18nextNamespacePrefix,
+			i18nextNamespaceTag)
+	}
+	if len(o.NamespaceTags) > 0 && o.Namespaces != i18nextNamespaceTag {
+		return fmt.Errorf("namespace tags need tag namespaces")
+	}
+	return nil
+}
+
+// i18nextFiles splits a locale's export into namespaces and nests the keys as the options say. the result maps each
+// namespace to its data, with "" for the whole locale when there are no namespaces. assetTags is only needed for tag
+// namespaces.
+func i18nextFiles(data interface{}, opts i18nextOptions, assetTags map[string][]string) (map[string]interface{}, error) {
+	if opts.Namespaces == "" && !opts.Nest {
+		return map[string]interface{}{"": data}, nil
+	}
+
+	flat := make(map[string]interface{})
+	flattenI18Next("", data, flat)
+	namespaces := map[string]map[string]interface{}{"": flat}
+	if opts.Namespaces != "" {
+		namespaces = splitNamespaces(flat, opts, assetTags)
+	}
+
+	files := make(map[string]interface{}, len(namespaces))
+	for ns, keys := range namespaces {
+		if !opts.Nest {
+			files[ns] = keys
+			continue
+		}
+		nested, err := nestI18NextKeys(keys)
+		if err != nil {
+			if ns != "" {
+				return nil, fmt.Errorf("namespace %s: %w", ns, err)
+			}
+			return nil, err
+		}
+		files[ns] = nested
+	}
+	return files, nil
+}
+
+// flattenI18Next adds the leaves of data to flat, with the keys of nested objects joined by dots
+func flattenI18Next(prefix string, data interface{}, flat map[string]interface{}) {
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		flat[prefix] = data
+		return
+	}
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + i18nextKeySeparator + key
+		}
+		flattenI18Next(key, value, flat)
+	}
+}
+
+func splitNamespaces(flat map[string]interface{}, opts i18nextOptions,
+	assetTags map[string][]string) map[string]map[string]interface{} {
+	defaultNamespace := opts.DefaultNamespace
+	if defaultNamespace == "" {
+		defaultNamespace = i18nextDefaultNamespace
+	}
+	namespaces := make(map[string]map[string]interface{})
+	add := func(ns, key string, value interface{}) {
+		if namespaces[ns] == nil {
+			namespaces[ns] = make(map[string]interface{})
+		}
+		namespaces[ns][key] = value
+	}
+
+	for key, value := range flat {
+		if opts.Namespaces == i18nextNamespacePrefix {
+			ns, rest, found := strings.Cut(key, i18nextKeySeparator)
+			if !found || ns == "" || rest == "" {
+				add(defaultNamespace, key, value)
+				continue
+			}
+			add(ns, rest, value)
+			continue
+		}
+
+		// an asset in more than one namespace tag is in each of those namespaces
+		tags, ok := assetTags[key]
+		if !ok {
+			tags = assetTags[i18nextPluralSuffix.ReplaceAllString(key, "")]
+		}
+		added := false
+		for _, tag := range tags {
+			if len(opts.NamespaceTags) > 0 && !slices.Contains(opts.NamespaceTags, tag) {
+				continue
+			}
+			add(tag, key, value)
+			added = true
+		}
+		if !added {
+			add(defaultNamespace, key, value)
+		}
+	}
+	return namespaces
+}
+
+// nestI18NextKeys turns dotted keys into nested objects. a key that is also the prefix of other keys can't be both a
+// string and an object, so every such collision is reported.
+func nestI18NextKeys(flat map[string]interface{}) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	// a key sorts before the keys it is a prefix of
+	sort.Strings(keys)
+
+	nested := make(map[string]interface{})
+	collisions := make([]string, 0)
+	for _, key := range keys {
+		segments := strings.Split(key, i18nextKeySeparator)
+		object := nested
+		for i, segment := range segments[:len(segments)-1] {
+			child, exists := object[segment]
+			if !exists {
+				child = make(map[string]interface{})
+				object[segment] = child
+			}
+			childObject, ok := child.(map[string]interface{})
+			if !ok {
+				collisions = append(collisions, fmt.Sprintf("%s is a string and the prefix of %s",
+					strings.Join(segments[:i+1], i18nextKeySeparator), key))
+				object = nil
+				break
+			}
+			object = childObject
+		}
+		if object == nil {
+			continue
+		}
+		last := segments[len(segments)-1]
+		if _, exists := object[last]; exists {
+			collisions = append(collisions, fmt.Sprintf("%s is a string and the prefix of other keys", key))
+			continue
+		}
+		object[last] = flat[key]
+	}
+	if len(collisions) > 0 {
+		return nil, fmt.Errorf("%w: can't nest the keys: %s", errValidation, strings.Join(collisions, "; "))
+	}
+	return nested, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestI18NextFiles(t *testing.T) {
+	data := map[string]interface{}{
+		"save":                 "Save",
+		"common.cancel":        "Cancel",
+		"common.files_one":     "{{count}} file",
+		"common.files_other":   "{{count}} files",
+		"import.drop.here":     "Drop here",
+		"import.drop.or-click": "or click",
+	}
+	assetTags := map[string][]string{
+		"save":          {"web", "checkout"},
+		"common.cancel": {"web"},
+		"common.files":  {"web"},
+	}
+	tests := []struct {
+		name string
+		opts i18nextOptions
+		want map[string]interface{}
+	}{
+		{name: "Flat", want: map[string]interface{}{"": data}},
+		{name: "Prefix", opts: i18nextOptions{Namespaces: i18nextNamespacePrefix, DefaultNamespace: "app"},
+			want: map[string]interface{}{
+				"app":    map[string]interface{}{"save": "Save"},
+				"common": map[string]interface{}{"cancel": "Cancel", "files_one": "{{count}} file", "files_other": "{{count}} files"},
+				"import": map[string]interface{}{"drop.here": "Drop here", "drop.or-click": "or click"},
+			}},
+		{name: "PrefixNested", opts: i18nextOptions{Namespaces: i18nextNamespacePrefix, Nest: true},
+			want: map[string]interface{}{
+				"translation": map[string]interface{}{"save": "Save"},
+				"common":      map[string]interface{}{"cancel": "Cancel", "files_one": "{{count}} file", "files_other": "{{count}} files"},
+				"import": map[string]interface{}{
+					"drop": map[string]interface{}{"here": "Drop here", "or-click": "or click"},
+				},
+			}},
+		{name: "Tag", opts: i18nextOptions{Namespaces: i18nextNamespaceTag, NamespaceTags: []string{"checkout", "web"},
+			DefaultNamespace: "translation"},
+			want: map[string]interface{}{
+				"checkout": map[string]interface{}{"save": "Save"},
+				"web": map[string]interface{}{"save": "Save", "common.cancel": "Cancel",
+					"common.files_one": "{{count}} file", "common.files_other": "{{count}} files"},
+				"translation": map[string]interface{}{"import.drop.here": "Drop here", "import.drop.or-click": "or click"},
+			}},
+		{name: "Nested", opts: i18nextOptions{Nest: true},
+			want: map[string]interface{}{"": map[string]interface{}{
+				"save": "Save",
+				"common": map[string]interface{}{"cancel": "Cancel", "files_one": "{{count}} file",
+					"files_other": "{{count}} files"},
+				"import": map[string]interface{}{
+					"drop": map[string]interface{}{"here": "Drop here", "or-click": "or click"},
+				},
+			}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := i18nextFiles(data, tt.opts, assetTags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("i18nextFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNestI18NextKeysCollision(t *testing.T) {
+	_, err := nestI18NextKeys(map[string]interface{}{
+		"menu":           "Menu",
+		"menu.open":      "Open",
+		"menu.open.hint": "Opens the menu",
+		"title":          "Title",
+	})
+	if err == nil {
+		t.Fatal("nestI18NextKeys() didn't report the collisions")
+	}
+	for _, want := range []string{"menu is a string and the prefix of menu.open", "menu is a string and the prefix of menu.open.hint"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestI18NextOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    i18nextOptions
+		wantErr bool
+	}{
+		{name: "None"},
+		{name: "Tag", opts: i18nextOptions{Namespaces: i18nextNamespaceTag, NamespaceTags: []string{"web"}}},
+		{name: "Unknown", opts: i18nextOptions{Namespaces: "suffix"}, wantErr: true},
+		{name: "TagsWithoutTagMode", opts: i18nextOptions{Namespaces: i18nextNamespacePrefix,
+			NamespaceTags: []string{"web"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
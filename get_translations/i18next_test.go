@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckI18NextICU(t *testing.T) {
+	export := func(de string) map[string]map[string]interface{} {
+		return map[string]map[string]interface{}{
+			"en-US": {"web": map[string]interface{}{"greeting": "Hello {{name}}",
+				"items": "{count, plural, one {# item} other {# items}}"}},
+			"de-DE": {"web": map[string]interface{}{"greeting": de,
+				"items": "{count, plural, one {# Artikel} other {# Artikel}}"}},
+		}
+	}
+	tests := []struct {
+		name    string
+		de      string
+		gate    *completionGate
+		wantErr error
+	}{
+		{name: "Interpolation", de: "Hallo {{name}}"},
+		{name: "Formatted", de: "Hallo {{name, uppercase}}"},
+		{name: "Unescaped", de: "Hallo {{- name}}"},
+		{name: "WrongArgument", de: "Hallo {{nom}}", wantErr: errValidation},
+		{name: "Malformed", de: "Hallo {name", wantErr: errValidation},
+		// a locale that isn't written isn't checked
+		{name: "HeldBack", de: "Hallo {name", gate: newCompletionGateFromReport(coverageReport{
+			Locales: []localeCoverage{{Locale: "de-DE", BelowThreshold: true}},
+		}, "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkI18NextICU(export(tt.de), "en-US", tt.gate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkI18NextICU() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
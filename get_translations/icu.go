@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+const (
+	icuTypePlural        = "plural"
+	icuTypeSelectOrdinal = "selectordinal"
+	icuTypeSelect        = "select"
+	icuOther             = "other"
+)
+
+// icuMessage is a parsed ICU MessageFormat message: text and arguments in order
+type icuMessage []icuPart
+
+type icuPart struct {
+	Text string
+	Arg  *icuArg
+}
+
+type icuArg struct {
+	Name string
+	// plural, select, selectordinal, number, date, ...; empty for a plain {name}
+	Type  string
+	Style string
+	// the cases of a plural, selectordinal or select
+	Cases []icuCase
+}
+
+type icuCase struct {
+	// a plural category, =N, or a select value
+	Selector string
+	Message  icuMessage
+}
+
+type icuParser struct {
+	s   []rune
+	pos int
+}
+
+// parseICU parses an ICU MessageFormat message, including apostrophe quoting
+func parseICU(s string) (icuMessage, error) {
+	p := &icuParser{s: []rune(s)}
+	msg, err := p.message(false, 0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unmatched }")
+	}
+	return msg, nil
+}
+
+func (p *icuParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *icuParser) peek() rune {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *icuParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// message reads up to the } that closes it, or the end when depth is 0. in a plural case, # is the number and can be
+// quoted.
+func (p *icuParser) message(inPlural bool, depth int) (icuMessage, error) {
+	var msg icuMessage
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			msg = append(msg, icuPart{Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\'':
+			p.quoted(&text, inPlural)
+		case c == '{':
+			flush()
+			arg, err := p.argument(depth)
+			if err != nil {
+				return nil, err
+			}
+			msg = append(msg, icuPart{Arg: arg})
+		case c == '}':
+			// the end of a case, or at the top an unmatched } that parseICU reports
+			flush()
+			return msg, nil
+		default:
+			text.WriteRune(c)
+			p.pos++
+		}
+	}
+	if depth > 0 {
+		return nil, p.errorf("missing }")
+	}
+	flush()
+	return msg, nil
+}
+
+// quoted reads an apostrophe: ” is a literal apostrophe, and one before a special character starts quoted text
+// that runs to the next lone apostrophe. any other apostrophe is literal.
+func (p *icuParser) quoted(text *strings.Builder, inPlural bool) {
+	p.pos++
+	next := p.peek()
+	switch {
+	case next == '\'':
+		text.WriteRune('\'')
+		p.pos++
+		return
+	case next == '{' || next == '}' || next == '|' || (inPlural && next == '#'):
+	default:
+		text.WriteRune('\'')
+		return
+	}
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		if c != '\'' {
+			text.WriteRune(c)
+			continue
+		}
+		if p.peek() != '\'' {
+			return
+		}
+		text.WriteRune('\'')
+		p.pos++
+	}
+}
+
+func (p *icuParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if unicode.IsSpace(c) || strings.ContainsRune("{},#'|", c) {
+			break
+		}
+		p.pos++
+	}
+	return string(p.s[start:p.pos])
+}
+
+// argument reads {name}, {name, type}, {name, type, style} or a plural, selectordinal or select with its cases
+func (p *icuParser) argument(depth int) (*icuArg, error) {
+	start := p.pos
+	p.pos++
+	p.skipSpace()
+	arg := &icuArg{Name: p.identifier()}
+	if arg.Name == "" {
+		return nil, p.errorf("missing argument name")
+	}
+	p.skipSpace()
+	switch p.peek() {
+	case '}':
+		p.pos++
+		return arg, nil
+	case ',':
+		p.pos++
+	case 0:
+		p.pos = start
+		return nil, p.errorf("unclosed {")
+	default:
+		return nil, p.errorf("unexpected %q in argument %s", p.peek(), arg.Name)
+	}
+
+	p.skipSpace()
+	arg.Type = p.identifier()
+	if arg.Type == "" {
+		return nil, p.errorf("missing type for argument %s", arg.Name)
+	}
+	p.skipSpace()
+	switch p.peek() {
+	case '}':
+		if arg.Type == icuTypePlural || arg.Type == icuTypeSelectOrdinal || arg.Type == icuTypeSelect {
+			return nil, p.errorf("%s %s has no cases", arg.Type, arg.Name)
+		}
+		p.pos++
+		return arg, nil
+	case ',':
+		p.pos++
+	case 0:
+		p.pos = start
+		return nil, p.errorf("unclosed {")
+	default:
+		return nil, p.errorf("unexpected %q in argument %s", p.peek(), arg.Name)
+	}
+
+	if arg.Type != icuTypePlural && arg.Type != icuTypeSelectOrdinal && arg.Type != icuTypeSelect {
+		// the style of a number, date or time argument, which can have nested braces of its own
+		styleStart, nested := p.pos, 0
+		for ; p.pos < len(p.s); p.pos++ {
+			switch p.s[p.pos] {
+			case '{':
+				nested++
+			case '}':
+				if nested == 0 {
+					arg.Style = strings.TrimSpace(string(p.s[styleStart:p.pos]))
+					p.pos++
+					return arg, nil
+				}
+				nested--
+			}
+		}
+		p.pos = start
+		return nil, p.errorf("unclosed {")
+	}
+
+	inPlural := arg.Type != icuTypeSelect
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case '}':
+			p.pos++
+			return arg, nil
+		case 0:
+			p.pos = start
+			return nil, p.errorf("unclosed {")
+		}
+		selector := p.identifier()
+		if selector == "" {
+			return nil, p.errorf("unexpected %q in %s %s", p.peek(), arg.Type, arg.Name)
+		}
+		if inPlural && strings.HasPrefix(selector, "offset:") {
+			continue
+		}
+		p.skipSpace()
+		if p.peek() != '{' {
+			return nil, p.errorf("missing { after %s in %s %s", selector, arg.Type, arg.Name)
+		}
+		p.pos++
+		msg, err := p.message(inPlural, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		// the case's closing }
+		p.pos++
+		arg.Cases = append(arg.Cases, icuCase{Selector: selector, Message: msg})
+	}
+}
+
+// args calls fn for every argument in the message, including the ones nested in cases
+func (m icuMessage) args(fn func(arg *icuArg)) {
+	for _, part := range m {
+		if part.Arg == nil {
+			continue
+		}
+		fn(part.Arg)
+		for _, c := range part.Arg.Cases {
+			c.Message.args(fn)
+		}
+	}
+}
+
+func (m icuMessage) argNames() map[string]bool {
+	names := make(map[string]bool)
+	m.args(func(arg *icuArg) { names[arg.Name] = true })
+	return names
+}
+
+// icuProblems checks an ICU message: the syntax, that every plural, selectordinal and select has an other case,
+// that the plural categories are ones CLDR has for the locale, and that the arguments are the ones in source
+func icuProblems(lang language.Tag, text, source string) []string {
+	msg, err := parseICU(text)
+	if err != nil {
+		return []string{fmt.Sprintf("malformed ICU: %v", err)}
+	}
+
+	problems := make([]string, 0)
+	msg.args(func(arg *icuArg) {
+		if len(arg.Cases) == 0 {
+			return
+		}
+		var categories map[string]bool
+		switch arg.Type {
+		case icuTypePlural:
+			categories = pluralCategories(plural.Cardinal, lang, true)
+		case icuTypeSelectOrdinal:
+			categories = pluralCategories(plural.Ordinal, lang, true)
+		}
+		seen := make(map[string]bool)
+		for _, c := range arg.Cases {
+			if seen[c.Selector] {
+				problems = append(problems, fmt.Sprintf("%s %s has %s twice", arg.Type, arg.Name, c.Selector))
+			}
+			seen[c.Selector] = true
+			switch {
+			case categories == nil:
+				// select values can be anything
+			case strings.HasPrefix(c.Selector, "="):
+				if _, err := strconv.ParseFloat(c.Selector[1:], 64); err != nil {
+					problems = append(problems, fmt.Sprintf("%s %s has an invalid exact match %s", arg.Type,
+						arg.Name, c.Selector))
+				}
+			case !categories[c.Selector]:
+				problems = append(problems, fmt.Sprintf("%s %s has %s, which %s doesn't use", arg.Type, arg.Name,
+					c.Selector, lang))
+			}
+		}
+		if !seen[icuOther] {
+			problems = append(problems, fmt.Sprintf("%s %s has no other case", arg.Type, arg.Name))
+		}
+	})
+
+	if source == "" || source == text {
+		return problems
+	}
+	sourceMsg, err := parseICU(source)
+	if err != nil {
+		// the source's own problems are reported for the source locale
+		return problems
+	}
+	if mismatch := argMismatch(sourceMsg.argNames(), msg.argNames()); mismatch != "" {
+		problems = append(problems, "arguments don't match the source: "+mismatch)
+	}
+	return problems
+}
+
+func argMismatch(source, target map[string]bool) string {
+	missing := make([]string, 0)
+	extra := make([]string, 0)
+	for name := range source {
+		if !target[name] {
+			missing = append(missing, name)
+		}
+	}
+	for name := range target {
+		if !source[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	problems := make([]string, 0, 2)
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, " "))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, "unexpected "+strings.Join(extra, " "))
+	}
+	return strings.Join(problems, ", ")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestParseICU(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    icuMessage
+		wantErr bool
+	}{
+		{name: "Text", in: "Save", want: icuMessage{{Text: "Save"}}},
+		{name: "Argument", in: "Hi { name }!", want: icuMessage{{Text: "Hi "}, {Arg: &icuArg{Name: "name"}}, {Text: "!"}}},
+		{name: "Number", in: "{n, number, ::percent}", want: icuMessage{{Arg: &icuArg{Name: "n", Type: "number",
+			Style: "::percent"}}}},
+		{name: "Plural", in: "{count, plural, offset:1 =0{none} one{# file} other{# files}}",
+			want: icuMessage{{Arg: &icuArg{Name: "count", Type: icuTypePlural, Cases: []icuCase{
+				{Selector: "=0", Message: icuMessage{{Text: "none"}}},
+				{Selector: "one", Message: icuMessage{{Text: "# file"}}},
+				{Selector: "other", Message: icuMessage{{Text: "# files"}}},
+			}}}}},
+		{name: "Nested", in: "{g, select, female{{n, plural, other{her #}}} other{their}}",
+			want: icuMessage{{Arg: &icuArg{Name: "g", Type: icuTypeSelect, Cases: []icuCase{
+				{Selector: "female", Message: icuMessage{{Arg: &icuArg{Name: "n", Type: icuTypePlural,
+					Cases: []icuCase{{Selector: "other", Message: icuMessage{{Text: "her #"}}}}}}}},
+				{Selector: "other", Message: icuMessage{{Text: "their"}}},
+			}}}}},
+		{name: "Quoted", in: "it''s '{literal}' and don't", want: icuMessage{{Text: "it's {literal} and don't"}}},
+		{name: "Unclosed", in: "Hi {name", wantErr: true},
+		{name: "Unmatched", in: "Hi name}", wantErr: true},
+		{name: "I18next", in: "Hi {{name}}", wantErr: true},
+		{name: "NoCases", in: "{n, plural}", wantErr: true},
+		{name: "UnclosedCase", in: "{n, plural, one{# file other{# files}}", wantErr: true},
+		{name: "CaseWithoutMessage", in: "{n, plural, one # file}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseICU(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseICU(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseICU(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestICUProblems(t *testing.T) {
+	const source = "{count, plural, one{# file} other{# files}} in {folder}"
+	tests := []struct {
+		name   string
+		locale string
+		text   string
+		want   []string
+	}{
+		{name: "Valid", locale: "de", text: "{count, plural, one{# Datei} other{# Dateien}} in {folder}",
+			want: []string{}},
+		{name: "RussianFew", locale: "ru",
+			text: "{count, plural, one{# файл} few{# файла} many{# файлов} other{# файла}} в {folder}",
+			want: []string{}},
+		{name: "FrenchMany", locale: "fr",
+			text: "{count, plural, one{# fichier} many{# de fichiers} other{# fichiers}} dans {folder}",
+			want: []string{}},
+		{name: "Category", locale: "en", text: "{count, plural, one{# file} few{# files} other{# files}} in {folder}",
+			want: []string{"plural count has few, which en doesn't use"}},
+		{name: "NoOther", locale: "en", text: "{count, plural, one{# file}} in {folder}",
+			want: []string{"plural count has no other case"}},
+		{name: "Exact", locale: "ja", text: "{count, plural, =1{1 件} =x{?} other{# 件}} {folder}",
+			want: []string{"plural count has an invalid exact match =x"}},
+		{name: "Arguments", locale: "de", text: "{count, plural, one{# Datei} other{# Dateien}} in {ordner}",
+			want: []string{"arguments don't match the source: missing folder, unexpected ordner"}},
+		{name: "Malformed", locale: "de", text: "{count, plural, one{# Datei} other{# Dateien} in {folder}",
+			want: []string{"malformed ICU: at 0: unclosed {"}},
+		{name: "Select", locale: "de", text: "{count, select, a{x} a{y}} {folder}",
+			want: []string{"select count has a twice", "select count has no other case"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := icuProblems(language.MustParse(tt.locale), tt.text, source)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("icuProblems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintTexts(t *testing.T) {
+	texts := map[string]map[string]string{
+		"en-US": {"files": "{n, plural, one{# file} other{# files}}", "hi": "Hi {name}"},
+		"de-DE": {"files": "{n, plural, one{# Datei}}", "hi": "Hallo {nom}"},
+	}
+	got := lintTexts(texts, "en-US", lintRules)
+	want := []lintIssue{
+		{Rule: lintRuleICU, Locale: "de-DE", ID: "files", Message: "plural n has no other case"},
+		{Rule: lintRuleICU, Locale: "de-DE", ID: "hi", Message: "arguments don't match the source: missing name, unexpected nom"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lintTexts() = %+v, want %+v", got, want)
+	}
+}
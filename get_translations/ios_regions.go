@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	pbxprojFilename = "project.pbxproj"
+	baseRegion      = "Base"
+	translatedState = "translated"
+)
+
+var (
+	knownRegionsRegex = regexp.MustCompile(`(?s)(knownRegions\s*=\s*\()(.*?)(\n?([ \t]*)\);)`)
+	// pbxproj values only need quoting when they contain something other than these
+	pbxprojBareValue = regexp.MustCompile(`^[A-Za-z0-9_./]+$`)
+	// directories that can be big and never contain the project we want
+	regionSearchSkipDirs = map[string]bool{".git": true, "Pods": true, "build": true, "DerivedData": true,
+		"node_modules": true}
+)
+
+// iosKnownRegions finds the locales the app ships with. knownRegions in the Xcode project is used when there is a
+// project.pbxproj in baseDir or its parent, otherwise the .lproj directories under baseDir. pbxPath is empty when
+// the regions did not come from a project file.
+func iosKnownRegions(baseDir string) (regions map[string]bool, pbxPath string, err error) {
+	pbxPath, err = findPbxproj(baseDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if pbxPath != "" {
+		data, readErr := os.ReadFile(pbxPath)
+		if readErr != nil {
+			return nil, "", readErr
+		}
+		regionList, parseErr := parseKnownRegions(data)
+		if parseErr != nil {
+			return nil, "", fmt.Errorf("%s: %w", pbxPath, parseErr)
+		}
+		slog.Info("using knownRegions", slog.String("project", pbxPath), slog.Int("count", len(regionList)))
+		return regionSet(regionList), pbxPath, nil
+	}
+
+	regionList, err := lprojRegions(baseDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(regionList) == 0 {
+		return nil, "", fmt.Errorf("no %s or .lproj directories found in %s", pbxprojFilename, baseDir)
+	}
+	slog.Info("using .lproj directories", slog.String("dir", baseDir), slog.Int("count", len(regionList)))
+	return regionSet(regionList), "", nil
+}
+
+func regionSet(regionList []string) map[string]bool {
+	regions := make(map[string]bool, len(regionList))
+	for _, region := range regionList {
+		if region != baseRegion {
+			regions[region] = true
+		}
+	}
+	return regions
+}
+
+// findPbxproj looks for an .xcodeproj under baseDir, then next to it, since the strings usually live in a directory
+// that sits beside the project. it fails when there's more than one where it looks, rather than pick one of them.
+func findPbxproj(baseDir string) (string, error) {
+	var found []string
+	_ = filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && regionSearchSkipDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if d.IsDir() && filepath.Ext(d.Name()) == ".xcodeproj" {
+			candidate := filepath.Join(path, pbxprojFilename)
+			if _, statErr := os.Stat(candidate); statErr == nil {
+				found = append(found, candidate)
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if len(found) == 0 {
+		found, _ = filepath.Glob(filepath.Join(filepath.Dir(filepath.Clean(baseDir)), "*.xcodeproj", pbxprojFilename))
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	sort.Strings(found)
+	return "", fmt.Errorf("more than one Xcode project for %s: %s", baseDir, strings.Join(found, ", "))
+}
+
+func parseKnownRegions(pbxproj []byte) ([]string, error) {
+	matches := knownRegionsRegex.FindSubmatch(pbxproj)
+	if matches == nil {
+		return nil, errors.New("knownRegions not found")
+	}
+
+	regions := make([]string, 0)
+	for _, item := range strings.Split(string(matches[2]), ",") {
+		region := strings.Trim(strings.TrimSpace(item), `"`)
+		if region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return regions, nil
+}
+
+func lprojRegions(baseDir string) ([]string, error) {
+	regions := make([]string, 0)
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if regionSearchSkipDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if filepath.Ext(d.Name()) == ".lproj" {
+			regions = append(regions, strings.TrimSuffix(d.Name(), ".lproj"))
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return regions, err
+}
+
+// addKnownRegions appends regions to the knownRegions list, keeping the indentation of the existing entries
+func addKnownRegions(pbxproj []byte, regions []string) ([]byte, error) {
+	loc := knownRegionsRegex.FindSubmatchIndex(pbxproj)
+	if loc == nil {
+		return nil, errors.New("knownRegions not found")
+	}
+	closingIndent := string(pbxproj[loc[8]:loc[9]])
+
+	var added strings.Builder
+	for _, region := range regions {
+		if !pbxprojBareValue.MatchString(region) {
+			region = `"` + region + `"`
+		}
+		added.WriteString(fmt.Sprintf("\n%s\t%s,", closingIndent, region))
+	}
+
+	// insert right before the newline that precedes the closing parenthesis
+	updated := make([]byte, 0, len(pbxproj)+added.Len())
+	updated = append(updated, pbxproj[:loc[6]]...)
+	updated = append(updated, added.String()...)
+	updated = append(updated, pbxproj[loc[6]:]...)
+	return updated, nil
+}
+
+// catalogCompletion returns the percentage of strings in the catalog that are translated for each locale
+func catalogCompletion(catalog XCodeStrings) map[string]float64 {
+	translated := make(map[string]int)
+	for _, asset := range catalog.Strings {
+		for rawLocale, valMap := range asset.Localizations {
+			locale := iosLocale(rawLocale)
+			if _, ok := translated[locale]; !ok {
+				translated[locale] = 0
+			}
+			if localizationTranslated(valMap) {
+				translated[locale]++
+			}
+		}
+	}
+
+	completion := make(map[string]float64, len(translated))
+	if len(catalog.Strings) == 0 {
+		return completion
+	}
+	for locale, count := range translated {
+		completion[locale] = 100 * float64(count) / float64(len(catalog.Strings))
+	}
+	return completion
+}
+
+// gatedCompletion is the catalogCompletion of the locales the gate lets through
+func gatedCompletion(catalog XCodeStrings, gate *completionGate) map[string]float64 {
+	completion := catalogCompletion(catalog)
+	for _, code := range gate.heldLocales() {
+		delete(completion, iosLocale(code))
+	}
+	return completion
+}
+
+// localizationTranslated reports whether an xcstrings localization is translated. plurals and device variations
+// count as translated when any of their string units are.
+func localizationTranslated(valMap map[string]any) bool {
+	if stringUnit, ok := valMap["stringUnit"].(map[string]any); ok {
+		return stringUnit["state"] == translatedState
+	}
+	variations, ok := valMap["variations"].(map[string]any)
+	if !ok {
+		return false
+	}
+	for _, variation := range variations {
+		cases, ok := variation.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, c := range cases {
+			if caseMap, ok := c.(map[string]any); ok && localizationTranslated(caseMap) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// regionsToAdd returns the locales that are not yet known regions but are at least threshold percent translated
+func regionsToAdd(completion map[string]float64, regions map[string]bool, threshold float64) []string {
+	toAdd := make([]string, 0)
+	for locale, pct := range completion {
+		if !regions[locale] && pct >= threshold {
+			toAdd = append(toAdd, locale)
+		}
+	}
+	sort.Strings(toAdd)
+	return toAdd
+}
+
+func updateKnownRegions(pbxPath string, regions []string) error {
+	info, err := os.Stat(pbxPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(pbxPath)
+	if err != nil {
+		return err
+	}
+	updated, err := addKnownRegions(data, regions)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pbxPath, err)
+	}
+	return run.record(pbxPath, os.WriteFile(pbxPath, updated, info.Mode()))
+}
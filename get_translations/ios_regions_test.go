@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPbxproj = `		83CBB9F61A601CBA00E9B192 /* Project object */ = {
+			isa = PBXProject;
+			developmentRegion = en;
+			hasScannedForEncodings = 0;
+			knownRegions = (
+				en,
+				Base,
+				"pt-PT",
+				"zh-Hans",
+			);
+			mainGroup = 83CBB9F61A601CBA00E9B192;
+		};
+`
+
+func TestParseKnownRegions(t *testing.T) {
+	regions, err := parseKnownRegions([]byte(testPbxproj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"en", "Base", "pt-PT", "zh-Hans"}
+	if !reflect.DeepEqual(regions, expected) {
+		t.Errorf("parseKnownRegions = %v, want %v", regions, expected)
+	}
+
+	if _, err = parseKnownRegions([]byte("isa = PBXProject;")); err == nil {
+		t.Error("expected an error when knownRegions is missing")
+	}
+}
+
+func TestAddKnownRegions(t *testing.T) {
+	updated, err := addKnownRegions([]byte(testPbxproj), []string{"fil", "sr-Latn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	regions, err := parseKnownRegions(updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"en", "Base", "pt-PT", "zh-Hans", "fil", "sr-Latn"}
+	if !reflect.DeepEqual(regions, expected) {
+		t.Errorf("regions after add = %v, want %v", regions, expected)
+	}
+
+	expectedText := `				"zh-Hans",
+				fil,
+				"sr-Latn",
+			);`
+	if !strings.Contains(string(updated), expectedText) {
+		t.Errorf("unexpected formatting:\n%s", updated)
+	}
+}
+
+func TestRegionsToAdd(t *testing.T) {
+	completion := map[string]float64{"en": 100, "de": 99, "fil": 95, "sk": 40}
+	regions := map[string]bool{"en": true, "de": true}
+	result := regionsToAdd(completion, regions, 90)
+	if !reflect.DeepEqual(result, []string{"fil"}) {
+		t.Errorf("regionsToAdd = %v, want [fil]", result)
+	}
+}
+
+func TestGatedCompletion(t *testing.T) {
+	translated := map[string]any{"stringUnit": map[string]any{"state": translatedState, "value": "x"}}
+	catalog := XCodeStrings{Strings: map[string]XCodeAsset{
+		"save": {Localizations: map[string]map[string]any{"de": translated, "fil": translated, "sk": translated}},
+	}}
+	// sk is fully translated in the catalog, but held back for the whole tag
+	gate := newCompletionGateFromReport(coverageReport{
+		Locales: []localeCoverage{{Locale: "sk", BelowThreshold: true}},
+	}, "")
+	result := regionsToAdd(gatedCompletion(catalog, gate), map[string]bool{"de": true}, 90)
+	if !reflect.DeepEqual(result, []string{"fil"}) {
+		t.Errorf("regionsToAdd = %v, want [fil]", result)
+	}
+}
+
+func TestFindPbxproj(t *testing.T) {
+	project := func(t *testing.T, dir string) string {
+		t.Helper()
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, pbxprojFilename)
+		if err := os.WriteFile(path, []byte(testPbxproj), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	root := t.TempDir()
+	baseDir := filepath.Join(root, "App")
+	want := project(t, filepath.Join(baseDir, "App.xcodeproj"))
+	// the Pods project is skipped
+	project(t, filepath.Join(baseDir, "Pods", "Pods.xcodeproj"))
+	if got, err := findPbxproj(baseDir); err != nil || got != want {
+		t.Errorf("findPbxproj() = %q, %v, want %q", got, err, want)
+	}
+
+	project(t, filepath.Join(baseDir, "Widget", "Widget.xcodeproj"))
+	if got, err := findPbxproj(baseDir); err == nil {
+		t.Errorf("findPbxproj() = %q with two projects, want an error", got)
+	}
+
+	// next to the strings directory
+	stringsDir := filepath.Join(root, "Next", "Strings")
+	if err := os.MkdirAll(stringsDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	want = project(t, filepath.Join(root, "Next", "Next.xcodeproj"))
+	if got, err := findPbxproj(stringsDir); err != nil || got != want {
+		t.Errorf("findPbxproj() = %q, %v, want %q", got, err, want)
+	}
+}
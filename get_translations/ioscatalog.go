@@ -4,12 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sync"
-	"sync/atomic"
 )
 
 type XCodeAsset struct {
@@ -56,13 +54,34 @@ var (
 	}
 )
 
-func updateiOSAssetsCatalog(apiKey, baseDir string) error {
+type iosCatalogOptions struct {
+	Strict bool
+	// the rules Info.plist values are checked against
+	PlistRules plistRuleSet
+	// locales that aren't in knownRegions yet are added once they are at least this percent translated. 0 disables.
+	AddRegionsThreshold float64
+	// locales less than this percent translated are left out of the catalogs. 0 disables.
+	MinCompletion float64
+	// pseudo-locales to generate from the source language
+	Pseudo []string
+}
+
+func updateiOSAssetsCatalog(apiKey, baseDir string, opts iosCatalogOptions) error {
 	// verify that baseDir is valid
 	if !isValidDir(baseDir) {
 		return fmt.Errorf("invalid base dir: %s", baseDir)
 	}
 
-	getTranslations := func(filter string) (*http.Response, error) {
+	err := validatePseudoLocales(opts.Pseudo)
+	if err != nil {
+		return err
+	}
+	regions, pbxPath, err := iosKnownRegions(baseDir)
+	if err != nil {
+		return err
+	}
+
+	getCatalog := func(filter string) (catalog XCodeStrings, err error) {
 		qp := url.Values{}
 		qp.Set(locoFilter, filter)
 		qp.Set("index", "id")
@@ -70,50 +89,93 @@ func updateiOSAssetsCatalog(apiKey, baseDir string) error {
 
 		resp, err := locoRequest(apiKey, iosCatalogURLTemplate, qp)
 		if err != nil {
-			return nil, err
+			return
 		}
-		return resp, nil
+		defer resp.Body.Close()
+		err = json.NewDecoder(resp.Body).Decode(&catalog)
+		return
 	}
 
+	// the main strings catalog decides if a locale is ready, as it does for adding regions
+	gate, err := newCompletionGate(apiKey, iosFilters[0], opts.MinCompletion)
+	if err != nil {
+		return err
+	}
+	defer gate.report()
+
 	wg := &sync.WaitGroup{}
 	wg.Add(len(iosFilters))
-	successCount := atomic.Int32{}
-	for _, f := range iosFilters {
-		filter := f
+	catalogs := make([]XCodeStrings, len(iosFilters))
+	fetchErrs := make([]error, len(iosFilters))
+	for i, f := range iosFilters {
+		i, filter := i, f
 		go func() {
 			defer wg.Done()
-			resp, err := getTranslations(filter)
-			if err != nil || resp.StatusCode != http.StatusOK {
-				slog.Error("error getting", slog.String("filter", filter),
-					slog.Int("status", resp.StatusCode), slog.Any("err", err.Error()))
-			} else {
-				err = processTranslationsCatalog(filter, baseDir, resp)
-				if err != nil {
-					slog.Error("error processing", slog.String("filter", filter),
-						slog.Any("err", err.Error()))
-				} else {
-					successCount.Add(1)
-				}
+			catalogs[i], fetchErrs[i] = getCatalog(filter)
+			if fetchErrs[i] != nil {
+				slog.Error("error getting", slog.String("filter", filter), slog.Any("err", fetchErrs[i]))
 			}
 		}()
 	}
 	wg.Wait()
 
-	if int(successCount.Load()) != len(iosFilters) {
+	if opts.AddRegionsThreshold > 0 && fetchErrs[0] == nil {
+		// the first filter is the main strings catalog, which is what decides if a locale is ready. the locales the
+		// gate holds back aren't written, so they aren't added to the project either.
+		newRegions := regionsToAdd(gatedCompletion(catalogs[0], gate), regions, opts.AddRegionsThreshold)
+		if len(newRegions) > 0 {
+			if pbxPath == "" {
+				slog.Warn("no Xcode project to add regions to", slog.Any("regions", newRegions))
+			} else if err = updateKnownRegions(pbxPath, newRegions); err != nil {
+				slog.Error("error adding regions", slog.Any("regions", newRegions), slog.Any("err", err))
+			} else {
+				slog.Info("added regions", slog.String("project", pbxPath), slog.Any("regions", newRegions))
+				for _, region := range newRegions {
+					regions[region] = true
+				}
+			}
+		}
+	}
+
+	// processTranslationsCatalog drops the locales that aren't in regions, and the plist rules only expect the rest
+	for _, code := range gate.heldLocales() {
+		delete(regions, iosLocale(code))
+	}
+	// only the main strings catalog gets pseudo-locales: lengthened Info.plist values would break the plist rules
+	if fetchErrs[0] == nil && len(opts.Pseudo) > 0 {
+		addPseudoLocalizations(catalogs[0], opts.Pseudo)
+		for _, locale := range opts.Pseudo {
+			regions[locale] = true
+		}
+	}
+
+	successCount := 0
+	for i, filter := range iosFilters {
+		if fetchErrs[i] != nil {
+			continue
+		}
+		err = processTranslationsCatalog(filter, baseDir, catalogs[i], regions, opts.Strict, opts.PlistRules)
+		if err != nil {
+			slog.Error("error processing", slog.String("filter", filter), slog.Any("err", err))
+		} else {
+			successCount++
+		}
+	}
+
+	if successCount == 0 {
 		return fmt.Errorf("did not process %d sets as expected", len(iosFilters))
 	}
+	if successCount != len(iosFilters) {
+		return fmt.Errorf("%w: processed %d of %d sets", errPartial, successCount, len(iosFilters))
+	}
 
 	return nil
 }
 
-func processTranslationsCatalog(filter, baseDir string, resp *http.Response) error {
-	defer resp.Body.Close()
-
-	var catalog XCodeStrings
-	err := json.NewDecoder(resp.Body).Decode(&catalog)
-	if err != nil {
-		return err
-	}
+// processTranslationsCatalog maps the locales in the catalog to the ones Xcode uses, drops the ones that aren't in
+// regions and writes the catalog to baseDir
+func processTranslationsCatalog(filter, baseDir string, catalog XCodeStrings, regions map[string]bool,
+	strict bool, plistRules plistRuleSet) error {
 	// basically this changes "en-US" to "en"
 	catalog.SourceLanguage = locales[catalog.SourceLanguage]
 
@@ -131,10 +193,10 @@ func processTranslationsCatalog(filter, baseDir string, resp *http.Response) err
 				catalog.Strings[asset].Localizations[locale] = v
 				locsToDelete = append(locsToDelete, rawLocale)
 			}
-			// if the lproj directory doesn't exist for this locale, then add it to the remove list; we don't want it
+			// if the app doesn't know about this locale, then add it to the remove list; we don't want it
 			if skippedAndLogged[locale] {
 				locsToDelete = append(locsToDelete, locale)
-			} else if !validiOSLocales[locale] {
+			} else if !regions[locale] {
 				slog.Info("skipping", slog.String("locale", locale))
 				locsToDelete = append(locsToDelete, locale)
 				skippedAndLogged[locale] = true
@@ -145,9 +207,6 @@ func processTranslationsCatalog(filter, baseDir string, resp *http.Response) err
 		}
 		for _, plKey := range plistAssetMap[asset] {
 			catalog.Strings[plKey] = catalog.Strings[asset]
-			if plKey == bundleNameAsset {
-				checkBundleNameLength(catalog.Strings[plKey].Localizations)
-			}
 			if plKey != asset {
 				assetsToDelete[asset] = struct{}{}
 			}
@@ -161,90 +220,56 @@ func processTranslationsCatalog(filter, baseDir string, resp *http.Response) err
 	outputFilename := stringsCatalogFilename
 	if isPlist {
 		outputFilename = plistCatalogFilename
+		violations := validatePlistCatalog(catalog, regions, plistRules)
+		for _, v := range violations {
+			slog.Warn("plist rule violation", slog.String("key", v.Key), slog.String("locale", v.Locale),
+				slog.String("reason", v.Reason))
+		}
+		if strict && len(violations) > 0 {
+			run.skipped(filepath.Join(baseDir, outputFilename), fmt.Sprintf("%d plist rule violations", len(violations)))
+			return fmt.Errorf("%w: %d plist rule violations, not writing %s", errValidation, len(violations), outputFilename)
+		}
 	}
 	outputPath := filepath.Join(baseDir, outputFilename)
 	outFile, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
-		return err
+		return run.record(outputPath, err)
 	}
 	defer outFile.Close()
-	err = json.NewEncoder(outFile).Encode(catalog)
-	return err
+	return run.record(outputPath, json.NewEncoder(outFile).Encode(catalog))
 }
 
-func checkBundleNameLength(localizations map[string]map[string]any) {
-	for locale, valMap := range localizations {
-		if stringUnit, ok := valMap["stringUnit"]; ok {
-			if suMap, ok := stringUnit.(map[string]any); ok {
-				valInt := suMap["value"]
-				var value string
-				value, _ = valInt.(string)
-				if len(value) == 0 || len(value) > 15 {
-					slog.Warn(bundleNameAsset+" too long", slog.String("locale", locale),
-						slog.Int("length", len(value)))
-				}
-			}
+// addPseudoLocalizations adds a localization for each pseudo-locale to every string, generated from the source
+// language. every value in the source localization is transformed, including plural and device variations.
+func addPseudoLocalizations(catalog XCodeStrings, pseudo []string) {
+	for _, asset := range catalog.Strings {
+		source, ok := asset.Localizations[catalog.SourceLanguage]
+		if !ok {
+			continue
+		}
+		for _, locale := range pseudo {
+			asset.Localizations[locale] = pseudolocalizeCatalogValues(locale, source)
 		}
 	}
 }
 
-var validiOSLocales = map[string]bool{
-	"en":      true,
-	"pt":      true,
-	"es":      true,
-	"it":      true,
-	"nl":      true,
-	"de":      true,
-	"fr":      true,
-	"pl":      true,
-	"sv":      true,
-	"pt-PT":   true,
-	"da":      true,
-	"sw":      true,
-	"lt":      true,
-	"ko":      true,
-	"ru":      true,
-	"cs":      true,
-	"hr":      true,
-	"ja":      true,
-	"bg":      true,
-	"ro":      true,
-	"hu":      true,
-	"uk":      true,
-	"el":      true,
-	"vi":      true,
-	"th":      true,
-	"et":      true,
-	"fi":      true,
-	"id":      true,
-	"ht":      true,
-	"kea":     true,
-	"sl":      true,
-	"fil":     true,
-	"gu":      true,
-	"tr":      true,
-	"sq":      true,
-	"zh-Hans": true,
-	"sk":      true,
-	"zh-Hant": true,
-	"af":      true,
-	"ee":      true,
-	"vec":     true,
-	"ca":      true,
-	"gl":      true,
-	"si":      true,
-	"jam":     true,
-	"hy":      true,
-	"ms":      true,
-	"ka":      true,
-	"az":      true,
-	"ne":      true,
-	"fon":     true,
-	"ak":      true,
-	"mfe":     true,
-	"sr-Latn": true,
-	"ar":      true,
-	"he":      true,
+func pseudolocalizeCatalogValues(locale string, localization map[string]any) map[string]any {
+	out := make(map[string]any, len(localization))
+	for key, value := range localization {
+		switch v := value.(type) {
+		case map[string]any:
+			out[key] = pseudolocalizeCatalogValues(locale, v)
+		case string:
+			if key == "value" {
+				out[key] = pseudolocalize(locale, v)
+			} else {
+				out[key] = v
+			}
+		default:
+			out[key] = v
+		}
+	}
+	return out
 }
 
 func iosLocale(rawLocaleName string) string {
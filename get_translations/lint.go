@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+const lintRuleICU = "icu"
+
+// lintRule returns the problems with a translation; source is the source locale's text for the asset
+type lintRule func(lang language.Tag, text, source string) []string
+
+var lintRules = map[string]lintRule{
+	lintRuleICU: icuProblems,
+}
+
+type lintOptions struct {
+	// only lint assets with this tag
+	Tag string
+	// the rules to run. every rule when empty
+	Rules []string
+}
+
+type lintIssue struct {
+	Rule    string
+	Locale  string
+	ID      string
+	Message string
+}
+
+// lintTranslations runs the lint rules over every translation in loco and fails when any of them finds a problem
+func lintTranslations(apiKey string, opts lintOptions) error {
+	rules, err := selectLintRules(opts.Rules)
+	if err != nil {
+		return err
+	}
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco")
+	}
+	assets, err := getAssets(apiKey, opts.Tag)
+	if err != nil {
+		return err
+	}
+	translations, err := fetchAllTranslations(apiKey, assets)
+	if err != nil {
+		return err
+	}
+
+	texts := make(map[string]map[string]string, len(allLocales))
+	for _, asset := range assets {
+		for _, translation := range translations[asset.ID] {
+			if !translation.Translated {
+				continue
+			}
+			if texts[translation.Locale.Code] == nil {
+				texts[translation.Locale.Code] = make(map[string]string)
+			}
+			texts[translation.Locale.Code][asset.ID] = translation.Translation
+		}
+	}
+	issues := lintTexts(texts, source.Code, rules)
+	writeLintIssues(reportOut, issues)
+	if len(issues) > 0 {
+		return fmt.Errorf("%w: lint found %d problems", errValidation, len(issues))
+	}
+	return nil
+}
+
+func selectLintRules(names []string) (map[string]lintRule, error) {
+	if len(names) == 0 {
+		return lintRules, nil
+	}
+	rules := make(map[string]lintRule, len(names))
+	for _, name := range names {
+		rule, ok := lintRules[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown lint rule %s", name)
+		}
+		rules[name] = rule
+	}
+	return rules, nil
+}
+
+// lintTexts runs the rules over texts, which maps each locale to its translations by asset ID. the issues are sorted
+// by locale, asset and rule.
+func lintTexts(texts map[string]map[string]string, sourceCode string, rules map[string]lintRule) []lintIssue {
+	ruleNames := make([]string, 0, len(rules))
+	for name := range rules {
+		ruleNames = append(ruleNames, name)
+	}
+	sort.Strings(ruleNames)
+
+	issues := make([]lintIssue, 0)
+	for locale, localeTexts := range texts {
+		lang := language.Make(locale)
+		for id, text := range localeTexts {
+			for _, name := range ruleNames {
+				for _, problem := range rules[name](lang, text, texts[sourceCode][id]) {
+					issues = append(issues, lintIssue{Rule: name, Locale: locale, ID: id, Message: problem})
+				}
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Locale != issues[j].Locale {
+			return issues[i].Locale < issues[j].Locale
+		}
+		if issues[i].ID != issues[j].ID {
+			return issues[i].ID < issues[j].ID
+		}
+		return issues[i].Rule < issues[j].Rule
+	})
+	return issues
+}
+
+func writeLintIssues(out io.Writer, issues []lintIssue) {
+	if len(issues) == 0 {
+		fmt.Fprintln(out, "no problems")
+		return
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(out, "%s %s: [%s] %s\n", issue.Locale, issue.ID, issue.Rule, issue.Message)
+	}
+}
+
+// i18nextTexts flattens each locale's i18next export into strings by key, for linting
+func i18nextTexts(localeCodes map[string]map[string]interface{}) map[string]map[string]string {
+	texts := make(map[string]map[string]string, len(localeCodes))
+	for locale, projects := range localeCodes {
+		texts[locale] = make(map[string]string)
+		for _, data := range projects {
+			flat := make(map[string]interface{})
+			flattenI18Next("", data, flat)
+			for key, value := range flat {
+				if s, ok := value.(string); ok && strings.TrimSpace(s) != "" {
+					texts[locale][key] = s
+				}
+			}
+		}
+	}
+	return texts
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+	redacted      = "REDACTED"
+)
+
+type logOptions struct {
+	// text or json
+	Format string
+	// debug, info, warn or error
+	Level string
+	// only log errors
+	Quiet bool
+}
+
+// newLogger makes the logger for the run. logs go to out, which is stderr, so that what the commands write to stdout
+// can be piped.
+func newLogger(out io.Writer, opts logOptions) (*slog.Logger, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(opts.Level))
+	if err != nil {
+		return nil, fmt.Errorf("unknown log level %s: use debug, info, warn or error", opts.Level)
+	}
+	if opts.Quiet {
+		level = slog.LevelError
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	switch opts.Format {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(out, handlerOpts)), nil
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(out, handlerOpts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %s: use %s or %s", opts.Format, logFormatText, logFormatJSON)
+}
+
+// redactURL is u for logging, with the values of query parameters that could hold credentials replaced. loco takes
+// the API key as a key parameter as well as in the Authorization header.
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	if u.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	query := u.Query()
+	for name := range query {
+		if sensitiveParam(name) {
+			query[name] = []string{redacted}
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+func sensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"key", "token", "secret", "password", "auth", "signature"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
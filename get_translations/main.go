@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -16,26 +19,111 @@ This is the "po" command mode.
 2. Generates the locales/asset_ids.go file. Usually run via go generate.
 This is the "assets" command mode.
 
-3. Pulls down the i18nextv4 format from loco and writes each locale to a separate json file.
+3. Pulls down the i18nextv4 format from loco and writes each locale to a separate json file. The keys can be split
+into namespaces by prefix or loco tag, written to <locale>/<namespace>.json, and nested into objects at the dots.
 This is the "json" command mode.
 
 4. Pulls down the yaml format for use with hugo.
 This is the "hugoyaml" command mode.
 
-5. Creates the list of BCP 47 fallback locales for each language.
+5. Creates the list of BCP 47 fallback locales for each language, as text, json, yaml, a go map or a typescript object.
+The fallbacks come from language matching, or with the cldr strategy from CLDR parent locales first.
 This is the "fallback" command mode.
 
-6. Pulls down the Android format and writes it into the resource directories.
+6. Pulls down the Android format and writes it into the resource directories. Optionally creates missing resource
+directories and generates locales_config.xml for per-app language support. The string resources are checked for escaping,
+plural and format argument problems before anything is written. With android modules in the config file, the base
+directory is the project root and each module's res directory gets only the strings routed to it.
 This is the "android" command mode.
 
 7. Pulls down the iOS strings and stringsdict and writes it into the Xcode project.
 This is the "ios" command mode.
 
-8. Pulls down the Xcode string catalogs and writes them.
+8. Pulls down the Xcode string catalogs and writes them. The locales written are the knownRegions of the Xcode project
+(or the .lproj directories if there is no project). The Info.plist values are checked against rules, e.g. the
+length of the bundle name; the config file can replace them under ios plist_rules and plist_default_rule.
 This is the "ioscat" command mode.
 
 9. Updates all the translations for an asset to change from python-style to i18next style formatting
-This is the "i18conv" command mode. Note that it requires an API key that allows writing.
+This is the "i18conv" command mode. Note that it requires an API key that allows writing, in LOCO_API_KEY.
+
+10. Keeps a PO tree like the one from the "po" mode up to date in a running container. Loco is polled, the tree is
+swapped out when the export changes and a process is sent SIGHUP and/or a sentinel file is touched. The swap is
+atomic when the directory is a symlink; a plain directory is briefly missing while it's replaced. Only the trees the
+watcher wrote, which have a marker file in them, are ever deleted. Polling stops when loco rejects the API key.
+This is the "watch" command mode.
+
+11. Extracts message IDs from go source (asset constants and gettext style calls), i18next t() calls in javascript and
+typescript, and POT files, and creates the ones that are missing from loco. Creating assets requires an API key that
+allows writing, in LOCO_API_KEY.
+This is the "push" command mode.
+
+12. Compares the assets in loco with the source trees listed under audit in the config file, per tag: go references to
+the asset constants, i18next keys in javascript, R.string in kotlin and String(localized:) in swift. Reports the assets
+nothing uses and the keys that loco doesn't have.
+This is the "audit" command mode.
+
+13. Reports how much of each locale is translated, overall and per tag, as a table, json or a markdown table of badges.
+Locales below the threshold are flagged and make the command fail.
+This is the "coverage" command mode.
+
+14. Exports an XLIFF 1.2 or 2.0 file per locale for translation outside loco, with the asset notes, context and the
+translation state, and imports the translated files. Imported translations whose placeholders don't match the source
+text in loco are skipped. Importing requires an API key that allows writing, in LOCO_API_KEY.
+This is the "xliff export" and "xliff import" command mode.
+
+15. Writes an app_<locale>.arb file per locale for flutter, with @key metadata from the asset notes and placeholders,
+and plurals as ICU messages.
+This is the "arb" command mode.
+
+16. Exports the translations as a CSV per locale, or one CSV with a column per locale, and optionally XLSX, for
+reviewers to edit in a spreadsheet, and imports the edited sheets. Only the translations that differ from loco are
+uploaded, after the changes are listed and confirmed. Importing requires an API key that allows writing, in
+LOCO_API_KEY.
+This is the "sheet export" and "sheet import" command mode.
+
+17. Checks every translation against lint rules and fails when there are problems. The icu rule parses ICU
+MessageFormat: balanced braces, an other case in every plural and select, plural categories that CLDR has for the
+locale, and the same arguments as the source. The json mode runs the same check with --icu.
+This is the "lint" command mode.
+
+18. Reports the loco project each configured API key is for, where the key came from and whether it can write. Loco
+doesn't tell a key's access level, so it is probed by creating a tag without a name, which loco rejects either way.
+This is the "whoami" command mode.
+
+The po, json, hugoyaml, arb, android and ioscat modes take --min-completion, which leaves out the locales that are less
+translated than that in the assets the mode exports (the tag it filters on, or the whole project without one), and
+logs which ones were held back. The po, json, android and ioscat modes take --pseudo, which
+adds en-XA and/or ar-XB pseudo-locales generated from the source locale with the placeholders left intact.
+
+Every mode exits with 2 when loco rejects the API key, 3 when loco can't be reached, 4 when the translations fail a
+check (e.g. android resource issues, plist rules, lint or coverage thresholds) and 5 when some of the files or
+uploads failed and the rest succeeded; 1 is any other error. With --output json a summary of the run, listing every
+file written, skipped or failed with the reason, is written to stdout instead of the reports.
+
+With --state-file the po, json, hugoyaml and android modes remember the ETag and Last-Modified that loco sent with
+each export, and on the next run ask for it only if it changed. An unchanged export is skipped entirely; when nothing
+changed, the run reports "no changes" on stdout (status unchanged in the json summary, with "no changes" as its
+message) and exits with 0. An export is downloaded
+in full again when the options that change what's written from it, e.g. --min-completion, --pseudo, the json
+namespaces or the android modules, differ from the last run. The state is only saved after a run that succeeded.
+
+With --metrics-file the run's duration, bytes downloaded, files written and failed, locales skipped, validation
+failures and the time of the last success are written for the node exporter's textfile collector, labelled with the
+command as the target; other targets in the file are kept. --metrics-push sends the same to a Pushgateway.
+
+Logs go to stderr, as text or json (--log-format) from --log-level up, or only errors with --quiet. The loco request
+URLs are logged at debug level, with any credentials in them redacted.
+
+The read only API key is taken from --api-key-file, LOCO_RO_API_KEY or the --credentials-helper command, in that
+order, and the key that allows writing from --write-api-key-file, LOCO_API_KEY or the helper, which is run with read or
+write as its last argument and prints the key. Each mode only asks for the keys it needs: the modes that write to loco
+need the writing key, except with --dry-run, and a writing key stands in for a missing read only one.
+
+The json and hugoyaml modes export the loco project the API key is for. With projects in the config file, they export
+each project with its own API key into a directory of its own, and don't need the default one. A project's key is read
+from its api_key_file, or else its api_key_env, or else asked from --credentials-helper with read and the project's
+name.
 */
 
 const (
@@ -44,22 +132,98 @@ const (
 	authHeader  = "Authorization"
 	locoBaseURL = "https://localise.biz/api"
 	tagMobile   = "mobile-apps"
+
+	minCompletionUsage = "leave out locales less than this percent translated instead of shipping source language fallbacks (0 disables)"
+	pseudoUsage        = "pseudo-locales to generate from the source locale: en-XA (accented and longer) and/or ar-XB (right to left)"
 )
 
 func main() {
-	apiKey := os.Getenv(apiKeyVar)
-	if apiKey == "" {
-		fmt.Printf("missing api key: provide it in the environment variable %s\n", apiKeyVar)
-		os.Exit(1)
-	}
+	start := time.Now()
+	// set from the credentials once the command to run is known
+	var apiKey, writeAPIKey string
+
+	var credOpts credentialOptions
+	var metricsOpts metricsOptions
+	var stateFile string
+	var configPath string
+	var output string
+	var logOpts logOptions
+	var iosOpts iosCatalogOptions
+	var androidOpts androidOptions
+	var fallbackOpts fallbackOptions
+	var watchOpts watchOptions
+	var pushOpts pushOptions
+	var auditStrict bool
+	var coverageOpts coverageOptions
+	var xliffOpts xliffExportOptions
+	var xliffDryRun bool
+	var sheetOpts sheetOptions
+	var i18nextOpts i18nextOptions
+	var lintOpts lintOptions
+	var sheetImportOpts sheetImportOptions
+	// for the exporters without their own options; only one command runs, so they can share it
+	var exportOpts exportOptions
 
 	rootCmd := &cobra.Command{
 		Use: "get_translations",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the flags and arguments were fine, so a failure from here on isn't a usage problem
+			cmd.SilenceUsage = true
+			logger, err := newLogger(os.Stderr, logOpts)
+			if err != nil {
+				return err
+			}
+			slog.SetDefault(logger)
+			switch output {
+			case outputText:
+			case outputJSON:
+				reportOut = os.Stderr
+			default:
+				return fmt.Errorf("unknown output %s: use %s or %s", output, outputText, outputJSON)
+			}
+			scopes := requiredScopes(cmd)
+			if usesProjectKeys(cmd) {
+				config, err := loadConfig(configPath)
+				if err != nil {
+					return err
+				}
+				if len(config.Projects) > 0 {
+					// each project has a key of its own
+					scopes = nil
+				}
+			}
+			apiKey, writeAPIKey, err = credOpts.keys(scopes)
+			if err != nil || stateFile == "" {
+				return err
+			}
+			lastExports, err = loadExportState(stateFile)
+			return err
+		},
 	}
+	rootCmd.PersistentFlags().StringVar(&logOpts.Format, "log-format", logFormatText, "log format: text or json")
+	rootCmd.PersistentFlags().StringVar(&logOpts.Level, "log-level", "info", "log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().BoolVarP(&logOpts.Quiet, "quiet", "q", false, "only log errors")
+	rootCmd.PersistentFlags().StringVar(&output, "output", outputText,
+		"text, or json for a summary of the run on stdout, with the files written, skipped and failed; reports go to stderr")
+	rootCmd.PersistentFlags().StringVar(&credOpts.ReadKeyFile, "api-key-file", "",
+		"file with the read only API key, instead of "+apiKeyVar)
+	rootCmd.PersistentFlags().StringVar(&credOpts.WriteKeyFile, "write-api-key-file", "",
+		"file with the API key that allows writing, instead of "+writeAPIKeyVar)
+	rootCmd.PersistentFlags().StringVar(&credOpts.Helper, "credentials-helper", "",
+		"command that prints the API key for the scope, read or write, given as its last argument")
+	rootCmd.PersistentFlags().StringVar(&metricsOpts.File, "metrics-file", "",
+		"write the run's metrics to this .prom file for the node exporter's textfile collector")
+	rootCmd.PersistentFlags().StringVar(&metricsOpts.PushURL, "metrics-push", "",
+		"push the run's metrics to the Pushgateway at this URL")
+	rootCmd.PersistentFlags().StringVar(&metricsOpts.Target, "metrics-target", "",
+		"target label of the metrics (default the command, e.g. po)")
+	rootCmd.PersistentFlags().StringVar(&stateFile, "state-file", "",
+		"file remembering the exports' ETag and Last-Modified, to skip the exports that haven't changed since the last run")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "yaml config file")
 	poCmd := &cobra.Command{
 		Use: "po <directory>",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return getPOExport(apiKey, args)
+			return getPOExport(apiKey, args, exportOpts)
 		},
 		Args: cobra.ExactArgs(1),
 	}
@@ -73,49 +237,117 @@ func main() {
 	jsonCmd := &cobra.Command{
 		Use: "json <directory>",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			filter := ""
 			if len(args) > 1 {
-				return getI18Next(apiKey, args[0], args[1])
-			} else {
-				return getI18Next(apiKey, args[0], "")
+				filter = args[1]
 			}
+			return forEachProject(apiKey, args[0], config.Projects, credOpts, func(apiKey, project, dir string) error {
+				return getI18Next(apiKey, project, dir, filter, exportOpts, i18nextOpts)
+			})
 		},
 		Args: cobra.MinimumNArgs(1),
 	}
+	jsonCmd.Flags().StringVar(&i18nextOpts.Namespaces, "namespaces", "",
+		"split each locale into <locale>/<namespace>.json by key prefix or loco tag: prefix or tag")
+	jsonCmd.Flags().StringSliceVar(&i18nextOpts.NamespaceTags, "namespace-tag", nil,
+		"with tag namespaces, a tag that is a namespace (default every tag)")
+	jsonCmd.Flags().StringVar(&i18nextOpts.DefaultNamespace, "default-namespace", i18nextDefaultNamespace,
+		"namespace of the keys without a prefix or namespace tag")
+	jsonCmd.Flags().BoolVar(&i18nextOpts.Nest, "nest", false, "nest dotted keys into objects")
+	jsonCmd.Flags().BoolVar(&i18nextOpts.ICU, "icu", false,
+		"fail when any locale has malformed ICU messages, plural categories the locale doesn't use or arguments that aren't in the source")
 	hugoYamlCmd := &cobra.Command{
 		Use: "hugoyaml <directory> [tag]",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			filter := ""
 			if len(args) > 1 {
-				return getHugoYaml(apiKey, args[0], args[1])
-			} else {
-				return getHugoYaml(apiKey, args[0], "")
+				filter = args[1]
 			}
+			return forEachProject(apiKey, args[0], config.Projects, credOpts, func(apiKey, project, dir string) error {
+				return getHugoYaml(apiKey, project, dir, filter, exportOpts)
+			})
 		},
 		Args: cobra.MinimumNArgs(1),
 	}
+	arbCmd := &cobra.Command{
+		Use: "arb <directory> [tag]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return exportARB(apiKey, args[0], args[1], exportOpts)
+			}
+			return exportARB(apiKey, args[0], "", exportOpts)
+		},
+		Args: cobra.RangeArgs(1, 2),
+	}
+	for _, cmd := range []*cobra.Command{poCmd, jsonCmd, hugoYamlCmd, arbCmd} {
+		cmd.Flags().Float64Var(&exportOpts.MinCompletion, "min-completion", 0, minCompletionUsage)
+	}
+	for _, cmd := range []*cobra.Command{poCmd, jsonCmd} {
+		cmd.Flags().StringSliceVar(&exportOpts.Pseudo, "pseudo", nil, pseudoUsage)
+	}
 
 	fallbackCmd := &cobra.Command{
 		Use: "fallback",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return getFallbackLangs(apiKey)
+			return getFallbackLangs(apiKey, fallbackOpts)
 		},
 	}
+	fallbackCmd.Flags().StringVar(&fallbackOpts.Format, "format", fallbackFormatText, "output format: text, json, yaml, go or ts")
+	fallbackCmd.Flags().StringVarP(&fallbackOpts.Output, "out-file", "o", "", "file to write the fallbacks to instead of stdout")
+	fallbackCmd.Flags().StringVar(&fallbackOpts.Package, "package", "locale", "package name for the go format")
+	fallbackCmd.Flags().StringVar(&fallbackOpts.Strategy, "strategy", fallbackStrategyDistance,
+		"distance uses language matching only; cldr follows CLDR parent locales and never crosses scripts first")
 
 	androidCmd := &cobra.Command{
 		Use: "android <directory>",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return updateAndroidAssets(apiKey, args[0], tagMobile)
+			config, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			androidOpts.Modules = config.Android.Modules
+			return updateAndroidAssets(apiKey, args[0], tagMobile, androidOpts)
 		},
 		Args: cobra.MinimumNArgs(1),
 	}
+	androidCmd.Flags().BoolVar(&androidOpts.CreateDirs, "create-dirs", false,
+		"create missing values-* resource directories instead of skipping those locales")
+	androidCmd.Flags().BoolVar(&androidOpts.LocalesConfig, "locales-config", false,
+		"generate xml/locales_config.xml listing every locale written")
+	androidCmd.Flags().BoolVar(&androidOpts.Strict, "strict", false,
+		"fail without writing anything when string resources have escaping, plural or format issues")
+	androidCmd.Flags().Float64Var(&androidOpts.MinCompletion, "min-completion", 0, minCompletionUsage)
+	androidCmd.Flags().StringSliceVar(&androidOpts.Pseudo, "pseudo", nil, pseudoUsage)
 
 	iosCatCmd := &cobra.Command{
 		Use:     "ioscat <directory>",
 		Aliases: []string{"ios"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return updateiOSAssetsCatalog(apiKey, args[0])
+			config, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			iosOpts.PlistRules, err = newPlistRuleSet(config.IOS)
+			if err != nil {
+				return err
+			}
+			return updateiOSAssetsCatalog(apiKey, args[0], iosOpts)
 		},
 		Args: cobra.MinimumNArgs(1),
 	}
+	iosCatCmd.Flags().BoolVar(&iosOpts.Strict, "strict", false, "fail instead of warning when Info.plist values break the plist rules")
+	iosCatCmd.Flags().Float64Var(&iosOpts.AddRegionsThreshold, "add-regions-threshold", 0,
+		"add locales to the Xcode project's knownRegions once they are at least this percent translated (0 disables)")
+	iosCatCmd.Flags().Float64Var(&iosOpts.MinCompletion, "min-completion", 0, minCompletionUsage)
+	iosCatCmd.Flags().StringSliceVar(&iosOpts.Pseudo, "pseudo", nil, pseudoUsage)
 
 	i18ConvCmd := &cobra.Command{
 		Use: "i18conv <asset>",
@@ -124,14 +356,164 @@ func main() {
 			if len(args) > 1 {
 				formatKey = args[1]
 			}
-			return i18nextConvertFormat(apiKey, args[0], formatKey)
+			return i18nextConvertFormat(writeAPIKey, args[0], formatKey)
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	watchCmd := &cobra.Command{
+		Use: "watch <directory>",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return watchPOExport(apiKey, args[0], watchOpts)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	watchCmd.Flags().DurationVar(&watchOpts.Interval, "interval", 5*time.Minute, "how often to check loco for changes")
+	watchCmd.Flags().DurationVar(&watchOpts.MaxBackoff, "max-backoff", time.Hour,
+		"longest wait between checks while loco is unavailable")
+	watchCmd.Flags().IntVar(&watchOpts.PID, "pid", 0, "process to send SIGHUP to after an update")
+	watchCmd.Flags().StringVar(&watchOpts.PIDFile, "pid-file", "", "file with the pid of the process to send SIGHUP to")
+	watchCmd.Flags().StringVar(&watchOpts.Sentinel, "sentinel", "", "file to touch after an update")
+
+	pushCmd := &cobra.Command{
+		Use: "push",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(pushOpts.GoDirs) == 0 && len(pushOpts.JSDirs) == 0 && len(pushOpts.POTFiles) == 0 {
+				return fmt.Errorf("nothing to extract from: use --go, --js or --pot")
+			}
+			return pushAssets(apiKey, writeAPIKey, pushOpts)
+		},
+		Args: cobra.NoArgs,
+	}
+	pushCmd.Flags().StringSliceVar(&pushOpts.GoDirs, "go", nil, "directory of go source to extract message IDs from")
+	pushCmd.Flags().StringVar(&pushOpts.AssetsFile, "assets-file", "",
+		"the generated asset_ids.go; its constants are recognized in the go source")
+	pushCmd.Flags().StringSliceVar(&pushOpts.JSDirs, "js", nil, "directory of javascript/typescript source with i18next t() calls")
+	pushCmd.Flags().StringSliceVar(&pushOpts.POTFiles, "pot", nil, "POT file to take message IDs from")
+	pushCmd.Flags().StringSliceVar(&pushOpts.Tags, "tag", nil, "tag to add to the new assets")
+	pushCmd.Flags().StringVar(&pushOpts.Notes, "notes", "", "notes to add to the new assets")
+	pushCmd.Flags().BoolVar(&pushOpts.DryRun, "dry-run", false, "list the assets that would be created without creating them")
+
+	auditCmd := &cobra.Command{
+		Use: "audit",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			return auditAssets(apiKey, config.Audit.Sources, auditStrict)
+		},
+		Args: cobra.NoArgs,
+	}
+	auditCmd.Flags().BoolVar(&auditStrict, "strict", false, "fail when there are unused or missing assets")
+
+	coverageCmd := &cobra.Command{
+		Use: "coverage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return reportCoverage(apiKey, coverageOpts)
+		},
+		Args: cobra.NoArgs,
+	}
+	coverageCmd.Flags().StringSliceVar(&coverageOpts.Tags, "tag", nil, "only report assets with these tags")
+	coverageCmd.Flags().Float64Var(&coverageOpts.Threshold, "threshold", 0,
+		"flag locales less than this percent translated, and fail if there are any (0 disables)")
+	coverageCmd.Flags().StringVar(&coverageOpts.Format, "format", coverageFormatTable, "output format: table, json or markdown")
+
+	xliffCmd := &cobra.Command{
+		Use: "xliff",
+	}
+	xliffExportCmd := &cobra.Command{
+		Use: "export <directory>",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportXLIFF(apiKey, args[0], xliffOpts)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	xliffExportCmd.Flags().StringVar(&xliffOpts.Version, "version", xliffVersion12, "XLIFF version: 1.2 or 2.0")
+	xliffExportCmd.Flags().StringVar(&xliffOpts.Tag, "tag", "", "only export assets with this tag")
+	xliffExportCmd.Flags().StringSliceVar(&xliffOpts.Locales, "locale", nil, "locales to export (default every locale but the source)")
+	xliffImportCmd := &cobra.Command{
+		Use: "import <file.xlf>...",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return importXLIFF(apiKey, writeAPIKey, args, xliffDryRun)
 		},
 		Args: cobra.MinimumNArgs(1),
 	}
+	xliffImportCmd.Flags().BoolVar(&xliffDryRun, "dry-run", false, "check the files without posting the translations")
+	xliffCmd.AddCommand(xliffExportCmd, xliffImportCmd)
 
-	rootCmd.AddCommand(poCmd, assetsCmd, jsonCmd, hugoYamlCmd, fallbackCmd, androidCmd, iosCatCmd, i18ConvCmd)
-	err := rootCmd.Execute()
-	if err != nil {
-		panic(err)
+	sheetCmd := &cobra.Command{
+		Use: "sheet",
+	}
+	sheetExportCmd := &cobra.Command{
+		Use: "export <directory>",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportSheets(apiKey, args[0], sheetOpts)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	sheetExportCmd.Flags().StringVar(&sheetOpts.Tag, "tag", "", "only export assets with this tag")
+	sheetExportCmd.Flags().StringSliceVar(&sheetOpts.Locales, "locale", nil, "locales to export (default every locale but the source)")
+	sheetExportCmd.Flags().BoolVar(&sheetOpts.Wide, "wide", false, "write one sheet with a column per locale")
+	sheetExportCmd.Flags().BoolVar(&sheetOpts.XLSX, "xlsx", false, "also write an XLSX workbook next to each CSV")
+	sheetImportCmd := &cobra.Command{
+		Use: "import <file.csv|file.xlsx>...",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return importSheets(apiKey, writeAPIKey, args, sheetImportOpts)
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+	sheetImportCmd.Flags().BoolVar(&sheetImportOpts.DryRun, "dry-run", false, "list the changes without uploading them")
+	sheetImportCmd.Flags().BoolVarP(&sheetImportOpts.Yes, "yes", "y", false, "upload the changes without asking")
+	sheetCmd.AddCommand(sheetExportCmd, sheetImportCmd)
+
+	lintCmd := &cobra.Command{
+		Use: "lint",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lintTranslations(apiKey, lintOpts)
+		},
+		Args: cobra.NoArgs,
+	}
+	lintCmd.Flags().StringVar(&lintOpts.Tag, "tag", "", "only lint assets with this tag")
+	lintCmd.Flags().StringSliceVar(&lintOpts.Rules, "rule", nil, "lint rule to run: icu (default every rule)")
+
+	whoamiCmd := &cobra.Command{
+		Use: "whoami",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return whoami(reportOut, credOpts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	requireKeys([]string{scopeRead}, poCmd, assetsCmd, jsonCmd, hugoYamlCmd, arbCmd, fallbackCmd, androidCmd, iosCatCmd,
+		watchCmd, auditCmd, coverageCmd, xliffExportCmd, sheetExportCmd, lintCmd)
+	requireKeys([]string{scopeRead, scopeWrite}, pushCmd, sheetImportCmd, xliffImportCmd)
+	requireKeys([]string{scopeWrite}, i18ConvCmd)
+	exportsProjects(jsonCmd, hugoYamlCmd)
+
+	rootCmd.AddCommand(poCmd, assetsCmd, jsonCmd, hugoYamlCmd, fallbackCmd, androidCmd, iosCatCmd, i18ConvCmd, watchCmd,
+		pushCmd, auditCmd, coverageCmd, xliffCmd, arbCmd, sheetCmd, lintCmd, whoamiCmd)
+	cmd, err := rootCmd.ExecuteC()
+	code := run.finish(cmd.CommandPath(), err)
+	if code == exitOK {
+		if stateErr := lastExports.save(); stateErr != nil {
+			slog.Error("error saving the state file", slog.Any("err", stateErr))
+		}
+		if run.Status == runUnchanged && output == outputText {
+			fmt.Fprintln(reportOut, noChanges)
+		}
+	}
+	if metricsOpts.File != "" || metricsOpts.PushURL != "" {
+		target := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()))
+		metricsErr := exportMetrics(metricsOpts, target, collectMetrics(run, time.Since(start), time.Now()))
+		if metricsErr != nil {
+			slog.Error("metrics failed", slog.Any("err", metricsErr))
+		}
+	}
+	if output == outputJSON {
+		if writeErr := run.write(os.Stdout); writeErr != nil {
+			fmt.Fprintln(os.Stderr, writeErr)
+		}
 	}
+	os.Exit(code)
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	metricsJob        = "get_translations"
+	metricLastSuccess = "get_translations_last_success_timestamp_seconds"
+)
+
+type metricsOptions struct {
+	// a .prom file for the node exporter's textfile collector
+	File string
+	// base URL of a Pushgateway
+	PushURL string
+	// the target label; the command when empty, e.g. po or xliff export
+	Target string
+}
+
+// downloadedBytes counts the bytes read from loco responses during the run
+var downloadedBytes atomic.Int64
+
+// countingBody counts what's read from a response body into downloadedBytes
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	downloadedBytes.Add(int64(n))
+	return n, err
+}
+
+type metricFamily struct {
+	name string
+	help string
+	kind string
+}
+
+// the families in the order they're written
+var metricFamilies = []metricFamily{
+	{name: "get_translations_duration_seconds", help: "How long the last run took.", kind: "gauge"},
+	{name: "get_translations_downloaded_bytes", help: "Bytes downloaded from loco in the last run.", kind: "gauge"},
+	{name: "get_translations_files_written", help: "Files written in the last run.", kind: "gauge"},
+	{name: "get_translations_files_failed", help: "Files that failed to be written or uploaded in the last run.",
+		kind: "gauge"},
+	{name: "get_translations_locales_skipped", help: "Locales left out of the last run, e.g. for being too little translated.",
+		kind: "gauge"},
+	{name: "get_translations_validation_failed", help: "1 if the last run failed a check of the translations.",
+		kind: "gauge"},
+	{name: "get_translations_exit_code", help: "Exit code of the last run.", kind: "gauge"},
+	{name: metricLastSuccess, help: "When the last successful run finished, in seconds since the epoch.", kind: "gauge"},
+}
+
+// runMetrics is one target's values, by metric name
+type runMetrics map[string]float64
+
+// collectMetrics sums up a finished run. a run that failed has no last success time, so that the previous one is
+// kept.
+func collectMetrics(s *runSummary, duration time.Duration, finished time.Time) runMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := runMetrics{
+		"get_translations_duration_seconds": duration.Seconds(),
+		"get_translations_downloaded_bytes": float64(downloadedBytes.Load()),
+		"get_translations_files_written":    0,
+		"get_translations_files_failed":     0,
+		"get_translations_locales_skipped":  0,
+		"get_translations_exit_code":        float64(s.ExitCode),
+	}
+	for _, f := range s.Files {
+		switch {
+		case f.Status == fileWritten:
+			m["get_translations_files_written"]++
+		case f.Status == fileFailed:
+			m["get_translations_files_failed"]++
+		case f.Status == fileSkipped && f.Locale != "":
+			m["get_translations_locales_skipped"]++
+		}
+	}
+	m["get_translations_validation_failed"] = 0
+	if s.ExitCode == exitValidation {
+		m["get_translations_validation_failed"] = 1
+	}
+	if s.ExitCode == exitOK {
+		m[metricLastSuccess] = float64(finished.Unix())
+	}
+	return m
+}
+
+// exportMetrics writes the run's metrics to the textfile and/or pushes them to the Pushgateway
+func exportMetrics(opts metricsOptions, target string, m runMetrics) error {
+	if opts.Target != "" {
+		target = opts.Target
+	}
+	if opts.File != "" {
+		err := writeMetricsFile(opts.File, target, m)
+		if err != nil {
+			return fmt.Errorf("writing metrics: %w", err)
+		}
+	}
+	if opts.PushURL != "" {
+		err := pushMetrics(opts.PushURL, target, m)
+		if err != nil {
+			return fmt.Errorf("pushing metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeMetricsFile replaces target's metrics in the textfile, keeping those of other targets and target's last
+// success time when this run failed. the file is replaced in one rename, so the collector never reads half of it.
+func writeMetricsFile(path, target string, m runMetrics) error {
+	targets := make(map[string]runMetrics)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		targets = parseMetrics(data)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	previous := targets[target]
+	targets[target] = m
+	if _, ok := m[metricLastSuccess]; !ok {
+		if last, ok := previous[metricLastSuccess]; ok {
+			m[metricLastSuccess] = last
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = encodeMetrics(tmp, targets)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// the collector runs as another user
+	err = os.Chmod(tmp.Name(), 0644) // #nosec G302 // metrics aren't secret
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var metricLine = regexp.MustCompile(`^(\w+)\{target="((?:[^"\\]|\\.)*)"\} (\S+)$`)
+
+// parseMetrics reads back a textfile written by encodeMetrics, by target
+func parseMetrics(data []byte) map[string]runMetrics {
+	targets := make(map[string]runMetrics)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		match := metricLine.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			continue
+		}
+		target := unescapeLabel(match[2])
+		if targets[target] == nil {
+			targets[target] = make(runMetrics)
+		}
+		targets[target][match[1]] = value
+	}
+	return targets
+}
+
+// encodeMetrics writes the metrics in the Prometheus text format, each family with its HELP and TYPE and a sample per
+// target
+func encodeMetrics(out io.Writer, targets map[string]runMetrics) error {
+	names := make([]string, 0, len(targets))
+	for target := range targets {
+		names = append(names, target)
+	}
+	sort.Strings(names)
+
+	w := bufio.NewWriter(out)
+	for _, family := range metricFamilies {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.kind)
+		for _, target := range names {
+			value, ok := targets[target][family.name]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "%s{target=\"%s\"} %s\n", family.name, escapeLabel(target),
+				strconv.FormatFloat(value, 'g', -1, 64))
+		}
+	}
+	return w.Flush()
+}
+
+// pushMetrics sends the metrics to the Pushgateway grouped by job and target. it POSTs, which replaces only the
+// metrics sent, so a failed run leaves the last success time that the Pushgateway has.
+func pushMetrics(pushURL, target string, m runMetrics) error {
+	var body bytes.Buffer
+	err := encodeMetrics(&body, map[string]runMetrics{target: m})
+	if err != nil {
+		return err
+	}
+	// the target is base64 encoded since it could have a slash in it
+	URL := fmt.Sprintf("%s/metrics/job/%s/target@base64/%s", strings.TrimSuffix(pushURL, "/"), metricsJob,
+		base64.RawURLEncoding.EncodeToString([]byte(target)))
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(URL, "text/plain; version=0.0.4", &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("status not OK: is %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var labelUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n")
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func unescapeLabel(s string) string {
+	return labelUnescaper.Replace(s)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectMetrics(t *testing.T) {
+	finished := time.Unix(1700000000, 0)
+	tests := []struct {
+		name  string
+		files func(s *runSummary)
+		err   error
+		want  runMetrics
+	}{
+		{name: "OK", files: func(s *runSummary) {
+			s.written("po/de.po")
+			s.written("po/fr.po")
+			s.skippedLocale("it", "below the minimum")
+			s.skipped("po/xx.po", "no directory")
+		}, want: runMetrics{
+			"get_translations_files_written": 2, "get_translations_files_failed": 0,
+			"get_translations_locales_skipped": 1, "get_translations_validation_failed": 0,
+			"get_translations_exit_code": exitOK, metricLastSuccess: 1700000000,
+		}},
+		{name: "Partial", files: func(s *runSummary) {
+			s.written("po/de.po")
+			_ = s.record("po/fr.po", errors.New("disk full"))
+		}, want: runMetrics{
+			"get_translations_files_written": 1, "get_translations_files_failed": 1,
+			"get_translations_locales_skipped": 0, "get_translations_validation_failed": 0,
+			"get_translations_exit_code": exitPartial,
+		}},
+		{name: "Validation", files: func(s *runSummary) {}, err: errValidation, want: runMetrics{
+			"get_translations_files_written": 0, "get_translations_files_failed": 0,
+			"get_translations_locales_skipped": 0, "get_translations_validation_failed": 1,
+			"get_translations_exit_code": exitValidation,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &runSummary{Files: make([]fileResult, 0)}
+			tt.files(s)
+			s.finish("get_translations po", tt.err)
+			got := collectMetrics(s, 2*time.Second, finished)
+			if got["get_translations_duration_seconds"] != 2 {
+				t.Errorf("duration = %v, want 2", got["get_translations_duration_seconds"])
+			}
+			delete(got, "get_translations_duration_seconds")
+			delete(got, "get_translations_downloaded_bytes")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectMetrics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "translations.prom")
+	runs := []struct {
+		target string
+		m      runMetrics
+	}{
+		{target: "po", m: runMetrics{"get_translations_files_written": 3, metricLastSuccess: 1700000000}},
+		{target: "json", m: runMetrics{"get_translations_files_written": 2, metricLastSuccess: 1700000100}},
+		// a failed run keeps the last success of the one before
+		{target: "po", m: runMetrics{"get_translations_files_written": 0, "get_translations_exit_code": 3}},
+	}
+	for _, r := range runs {
+		if err := writeMetricsFile(path, r.target, r.m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parseMetrics(data)
+	want := map[string]runMetrics{
+		"po": {"get_translations_files_written": 0, "get_translations_exit_code": 3,
+			metricLastSuccess: 1700000000},
+		"json": {"get_translations_files_written": 2, metricLastSuccess: 1700000100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metrics =\n%v\nwant\n%v", got, want)
+	}
+	if !strings.Contains(string(data), "# TYPE get_translations_files_written gauge\n") {
+		t.Errorf("metrics file has no TYPE lines:\n%s", data)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody = r.URL.Path, string(body)
+	}))
+	defer server.Close()
+
+	err := pushMetrics(server.URL+"/", "xliff export", runMetrics{"get_translations_files_written": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := "/metrics/job/get_translations/target@base64/" + base64.RawURLEncoding.EncodeToString([]byte("xliff export"))
+	if gotPath != wantPath {
+		t.Errorf("path = %s, want %s", gotPath, wantPath)
+	}
+	if !strings.Contains(gotBody, "get_translations_files_written{target=\"xliff export\"} 4\n") {
+		t.Errorf("body doesn't have the sample:\n%s", gotBody)
+	}
+}
+
+func TestMetricsLabelEscaping(t *testing.T) {
+	targets := map[string]runMetrics{`po "web"\n`: {"get_translations_exit_code": 1}}
+	var b strings.Builder
+	if err := encodeMetrics(&b, targets); err != nil {
+		t.Fatal(err)
+	}
+	if got := parseMetrics([]byte(b.String())); !reflect.DeepEqual(got, targets) {
+		t.Errorf("parseMetrics(encodeMetrics()) = %v, want %v", got, targets)
+	}
+}
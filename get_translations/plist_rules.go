@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// plistRule describes the constraints a localized Info.plist value has to satisfy before it is written
+type plistRule struct {
+	MaxGraphemes       int  `yaml:"max_graphemes"` // 0 means no limit
+	NonEmpty           bool `yaml:"non_empty"`
+	NoFormatSpecifiers bool `yaml:"no_format_specifiers"`
+	RequireAllLocales  bool `yaml:"require_all_locales"`
+}
+
+// plistRuleSet is the rule for each Info.plist key, and the rule for the keys without one
+type plistRuleSet struct {
+	Rules   map[string]plistRule
+	Default plistRule
+}
+
+func (r plistRuleSet) rule(key string) plistRule {
+	if rule, ok := r.Rules[key]; ok {
+		return rule
+	}
+	return r.Default
+}
+
+// newPlistRuleSet is the built in rules with the ones from the config file in place of them. a configured rule
+// replaces the built in one for its key whole, so the fields it leaves out are off.
+func newPlistRuleSet(config IOSConfig) (plistRuleSet, error) {
+	rules := plistRuleSet{Rules: make(map[string]plistRule, len(plistRules)+len(config.PlistRules)),
+		Default: plistDefaultRule}
+	for key, rule := range plistRules {
+		rules.Rules[key] = rule
+	}
+	for key, rule := range config.PlistRules {
+		if !isPlistKey(key) {
+			return plistRuleSet{}, fmt.Errorf("plist rule for %s: not an Info.plist key in the string catalog", key)
+		}
+		rules.Rules[key] = rule
+	}
+	if config.PlistDefaultRule != nil {
+		rules.Default = *config.PlistDefaultRule
+	}
+	return rules, nil
+}
+
+func isPlistKey(key string) bool {
+	for _, plKeys := range plistAssetMap {
+		if slices.Contains(plKeys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+type plistViolation struct {
+	Key    string
+	Locale string
+	Reason string
+}
+
+func (v plistViolation) String() string {
+	if v.Locale == "" {
+		return fmt.Sprintf("%s: %s", v.Key, v.Reason)
+	}
+	return fmt.Sprintf("%s [%s]: %s", v.Key, v.Locale, v.Reason)
+}
+
+var (
+	// the built in rules for the keys that end up in InfoPlist.xcstrings. keys in plistAssetMap without an entry use
+	// plistDefaultRule. the config file can replace both.
+	plistRules = map[string]plistRule{
+		// the home screen truncates anything longer than this
+		bundleNameAsset: {MaxGraphemes: 15, NonEmpty: true, NoFormatSpecifiers: true, RequireAllLocales: true},
+	}
+
+	plistDefaultRule = plistRule{NonEmpty: true, NoFormatSpecifiers: true, RequireAllLocales: true}
+
+	// python, i18next, printf and objective-c style placeholders. none of them are substituted in Info.plist values.
+	formatSpecifierRegex = regexp.MustCompile(
+		`%(?:\d+\$)?(?:\([\w-]+\))?[-+ #0]*\d*(?:\.\d+)?(?:hh|h|ll|l|q|z|t|j)?[@dDuUxXoOfeEgGcCsSpaAF]|\{\{[\w.-]+\}\}`)
+)
+
+// validatePlistCatalog checks every plist key in the catalog against its rule. shipped is the set of locales the app
+// ships with; keys whose rule requires all locales must have a value for each of them.
+func validatePlistCatalog(catalog XCodeStrings, shipped map[string]bool, rules plistRuleSet) []plistViolation {
+	violations := make([]plistViolation, 0)
+	checked := make(map[string]bool)
+	for _, plKeys := range plistAssetMap {
+		for _, plKey := range plKeys {
+			if checked[plKey] {
+				continue
+			}
+			checked[plKey] = true
+
+			rule := rules.rule(plKey)
+			asset, ok := catalog.Strings[plKey]
+			if !ok {
+				if rule.RequireAllLocales {
+					violations = append(violations, plistViolation{Key: plKey, Reason: "missing from catalog"})
+				}
+				continue
+			}
+			violations = append(violations, validatePlistAsset(plKey, rule, asset.Localizations, shipped)...)
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Key != violations[j].Key {
+			return violations[i].Key < violations[j].Key
+		}
+		return violations[i].Locale < violations[j].Locale
+	})
+	return violations
+}
+
+func validatePlistAsset(key string, rule plistRule, localizations map[string]map[string]any,
+	shipped map[string]bool) []plistViolation {
+	violations := make([]plistViolation, 0)
+	for locale, valMap := range localizations {
+		value := stringUnitValue(valMap)
+		if rule.NonEmpty && strings.TrimSpace(value) == "" {
+			violations = append(violations, plistViolation{Key: key, Locale: locale, Reason: "empty value"})
+			continue
+		}
+		if length := graphemeLength(value); rule.MaxGraphemes > 0 && length > rule.MaxGraphemes {
+			violations = append(violations, plistViolation{Key: key, Locale: locale,
+				Reason: fmt.Sprintf("%d characters, max is %d", length, rule.MaxGraphemes)})
+		}
+		if rule.NoFormatSpecifiers {
+			if spec := formatSpecifierRegex.FindString(strings.ReplaceAll(value, "%%", "")); spec != "" {
+				violations = append(violations, plistViolation{Key: key, Locale: locale,
+					Reason: fmt.Sprintf("contains format specifier %q", spec)})
+			}
+		}
+	}
+
+	if rule.RequireAllLocales {
+		for locale := range shipped {
+			if _, ok := localizations[locale]; !ok {
+				violations = append(violations, plistViolation{Key: key, Locale: locale, Reason: "no translation"})
+			}
+		}
+	}
+	return violations
+}
+
+// stringUnitValue pulls the value out of an xcstrings localization, e.g. {"stringUnit": {"value": "..."}}
+func stringUnitValue(valMap map[string]any) string {
+	stringUnit, ok := valMap["stringUnit"]
+	if !ok {
+		return ""
+	}
+	suMap, ok := stringUnit.(map[string]any)
+	if !ok {
+		return ""
+	}
+	value, _ := suMap["value"].(string)
+	return value
+}
+
+// graphemeLength approximates the number of user-perceived characters in s. combining marks, variation selectors,
+// emoji modifiers and anything joined by a zero width joiner are counted as part of the preceding character, and
+// regional indicators are counted in pairs (flags).
+func graphemeLength(s string) int {
+	const (
+		zwj              = '\u200d'
+		regionalIndStart = '\U0001F1E6'
+		regionalIndEnd   = '\U0001F1FF'
+	)
+	count := 0
+	joinNext := false
+	pendingRegional := false
+	for _, r := range s {
+		switch {
+		case r == zwj:
+			joinNext = true
+			continue
+		case joinNext:
+			joinNext = false
+			continue
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector):
+			continue
+		case r >= '\U0001F3FB' && r <= '\U0001F3FF':
+			// skin tone modifiers
+			continue
+		case r >= regionalIndStart && r <= regionalIndEnd:
+			if pendingRegional {
+				pendingRegional = false
+				continue
+			}
+			pendingRegional = true
+			count++
+			continue
+		}
+		pendingRegional = false
+		count++
+	}
+	return count
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGraphemeLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{name: "ASCII", input: "Hourglass", expected: 9},
+		{name: "Empty", input: "", expected: 0},
+		{name: "Multibyte", input: "Ημερολόγιο", expected: 10},
+		{name: "Combining mark", input: "Cafe\u0301", expected: 4},
+		{name: "CJK", input: "沙漏", expected: 2},
+		{name: "Flag", input: "\U0001F1E7\U0001F1F7", expected: 1},
+		{name: "Skin tone", input: "\U0001F44D\U0001F3FD", expected: 1},
+		{name: "ZWJ sequence", input: "\U0001F468\u200d\U0001F469\u200d\U0001F467", expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := graphemeLength(tt.input)
+			if result != tt.expected {
+				t.Errorf("graphemeLength(%q) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePlistAsset(t *testing.T) {
+	value := func(s string) map[string]any {
+		return map[string]any{"stringUnit": map[string]any{"state": "translated", "value": s}}
+	}
+	rule := plistRules[bundleNameAsset]
+	shipped := map[string]bool{"en": true, "de": true}
+
+	tests := []struct {
+		name          string
+		localizations map[string]map[string]any
+		expected      int
+	}{
+		{
+			name:          "Valid",
+			localizations: map[string]map[string]any{"en": value("Hourglass"), "de": value("Sanduhr")},
+			expected:      0,
+		},
+		{
+			name:          "Too long",
+			localizations: map[string]map[string]any{"en": value("Hourglass"), "de": value("Sanduhr für Versammlungen")},
+			expected:      1,
+		},
+		{
+			name:          "Empty",
+			localizations: map[string]map[string]any{"en": value("Hourglass"), "de": value(" ")},
+			expected:      1,
+		},
+		{
+			name:          "Format specifier",
+			localizations: map[string]map[string]any{"en": value("%(app)s"), "de": value("{{app}}")},
+			expected:      2,
+		},
+		{
+			name:          "Escaped percent",
+			localizations: map[string]map[string]any{"en": value("100%% Hourglass"), "de": value("Sanduhr")},
+			expected:      0,
+		},
+		{
+			name:          "Missing locale",
+			localizations: map[string]map[string]any{"en": value("Hourglass")},
+			expected:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := validatePlistAsset(bundleNameAsset, rule, tt.localizations, shipped)
+			if len(violations) != tt.expected {
+				t.Errorf("got %d violations, want %d: %v", len(violations), tt.expected, violations)
+			}
+		})
+	}
+}
+
+func TestNewPlistRuleSet(t *testing.T) {
+	lenient := plistRule{NonEmpty: true}
+	tests := []struct {
+		name    string
+		config  IOSConfig
+		key     string
+		want    plistRule
+		wantErr bool
+	}{
+		{name: "BuiltIn", key: bundleNameAsset, want: plistRules[bundleNameAsset]},
+		{name: "BuiltInDefault", key: "NSCameraUsageDescription", want: plistDefaultRule},
+		{name: "Configured", config: IOSConfig{PlistRules: map[string]plistRule{bundleNameAsset: {MaxGraphemes: 12}}},
+			key: bundleNameAsset, want: plistRule{MaxGraphemes: 12}},
+		{name: "ConfiguredDefault", config: IOSConfig{PlistDefaultRule: &lenient}, key: "NSCameraUsageDescription",
+			want: lenient},
+		{name: "DefaultKeepsBuiltInRules", config: IOSConfig{PlistDefaultRule: &lenient}, key: bundleNameAsset,
+			want: plistRules[bundleNameAsset]},
+		{name: "UnknownKey", config: IOSConfig{PlistRules: map[string]plistRule{"NSNothing": {}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := newPlistRuleSet(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newPlistRuleSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := rules.rule(tt.key); got != tt.want {
+				t.Errorf("rule(%s) = %+v, want %+v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlistRulesConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte("ios:\n  plist_rules:\n    CFBundleName:\n      max_graphemes: 12\n      non_empty: true\n" +
+		"  plist_default_rule:\n    require_all_locales: true\n")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IOSConfig{
+		PlistRules:       map[string]plistRule{"CFBundleName": {MaxGraphemes: 12, NonEmpty: true}},
+		PlistDefaultRule: &plistRule{RequireAllLocales: true},
+	}
+	if !reflect.DeepEqual(config.IOS, want) {
+		t.Errorf("ios config = %+v, want %+v", config.IOS, want)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// newerCardinalCategories are the cardinal categories added in CLDR releases newer than the plural rules in x/text,
+// e.g. many for whole millions in french
+var newerCardinalCategories = map[string][]string{
+	"ca": {"many"},
+	"es": {"many"},
+	"fr": {"many"},
+	"it": {"many"},
+	"pt": {"many"},
+}
+
+type pluralCategoriesKey struct {
+	rules    *plural.Rules
+	lang     language.Tag
+	decimals bool
+}
+
+// working out the categories takes thousands of matches, so they're kept for the next message in the locale
+var (
+	pluralCategoriesMu    sync.Mutex
+	pluralCategoriesCache = make(map[pluralCategoriesKey]map[string]bool)
+)
+
+// pluralCategories returns the plural categories the CLDR rules for lang use, for whole numbers and, with decimals,
+// for decimals as well. other is always one of them. the lint rules and the android validation share it so that they
+// agree on what a locale needs.
+func pluralCategories(rules *plural.Rules, lang language.Tag, decimals bool) map[string]bool {
+	key := pluralCategoriesKey{rules: rules, lang: lang, decimals: decimals}
+	pluralCategoriesMu.Lock()
+	defer pluralCategoriesMu.Unlock()
+	if categories, ok := pluralCategoriesCache[key]; ok {
+		return categories
+	}
+
+	categories := map[string]bool{pluralFormNames[plural.Other]: true}
+	add := func(i, v, w, f, t int) {
+		categories[pluralFormNames[rules.MatchPlural(lang, i, v, w, f, t)]] = true
+	}
+	for i := 0; i <= 1000; i++ {
+		add(i, 0, 0, 0, 0)
+		if !decimals {
+			continue
+		}
+		for f := 1; f <= 9; f++ {
+			add(i, 1, 1, f, f)
+		}
+	}
+	if rules == plural.Cardinal {
+		base, _ := lang.Base()
+		for _, category := range newerCardinalCategories[base.String()] {
+			categories[category] = true
+		}
+	}
+	pluralCategoriesCache[key] = categories
+	return categories
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+func TestPluralCategories(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    *plural.Rules
+		locale   string
+		decimals bool
+		want     []string
+	}{
+		{name: "English", rules: plural.Cardinal, locale: "en", want: []string{"one", "other"}},
+		{name: "FrenchMany", rules: plural.Cardinal, locale: "fr", want: []string{"many", "one", "other"}},
+		{name: "FrenchOrdinal", rules: plural.Ordinal, locale: "fr", want: []string{"one", "other"}},
+		{name: "EnglishOrdinal", rules: plural.Ordinal, locale: "en", want: []string{"few", "one", "other", "two"}},
+		// 1.5 is few in russian, but so are whole numbers like 2
+		{name: "RussianDecimals", rules: plural.Cardinal, locale: "ru", decimals: true,
+			want: []string{"few", "many", "one", "other"}},
+		{name: "Japanese", rules: plural.Cardinal, locale: "ja", want: []string{"other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			categories := pluralCategories(tt.rules, language.MustParse(tt.locale), tt.decimals)
+			got := make([]string, 0, len(categories))
+			for category := range categories {
+				got = append(got, category)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pluralCategories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
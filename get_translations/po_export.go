@@ -3,6 +3,7 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -19,22 +20,76 @@ const (
 	backendTag      = "backend"
 )
 
-func getPOExport(apiKey string, args []string) error {
-	qp := url.Values{}
-	qp.Add("index", "name")
-	qp.Add(locoFilter, backendTag)
-	qp.Add("fallback", "en-US")
-
-	resp, err := locoRequest(apiKey, locoPOExportURL, qp)
+func getPOExport(apiKey string, args []string, opts exportOptions) error {
+	err := validatePseudoLocales(opts.Pseudo)
+	if err != nil {
+		return err
+	}
+	resp, err := lastExports.fetch(apiKey, locoPOExportURL, poExportQuery(), args[0], opts)
+	if errors.Is(err, errNotModified) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	gate, err := newCompletionGate(apiKey, backendTag, opts.MinCompletion)
+	if err != nil {
+		return err
+	}
+
+	err = writeLocoPO(args[0], resp.Body, gate)
+	gate.report()
+	if err != nil || len(opts.Pseudo) == 0 {
+		return err
+	}
+	return writePseudoPO(apiKey, args[0], opts.Pseudo)
+}
+
+// writePseudoPO generates the pseudo-locales from the source locale's PO file, which has just been written
+func writePseudoPO(apiKey, baseDir string, pseudo []string) error {
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco")
+	}
+	sourceDir := locales[source.Code]
+	if sourceDir == "" {
+		sourceDir = source.Code
+	}
+	data, err := os.ReadFile(filepath.Join(baseDir, sourceDir, "LC_MESSAGES", "messages.po"))
+	if err != nil {
+		return err
+	}
+	for _, locale := range pseudo {
+		poDir := filepath.Join(baseDir, locale, "LC_MESSAGES")
+		err = os.MkdirAll(poDir, os.ModePerm)
+		if err != nil {
+			return err
+		}
+		poPath := filepath.Join(poDir, "messages.po")
+		err = run.record(poPath, os.WriteFile(poPath, pseudolocalizePO(locale, data), 0666))
+		if err != nil {
+			return err
+		}
+		slog.Info("wrote pseudo-locale", slog.String("locale", locale))
+	}
+	return nil
+}
 
-	return writeLocoPO(args[0], resp.Body)
+func poExportQuery() url.Values {
+	qp := url.Values{}
+	qp.Add("index", "name")
+	qp.Add(locoFilter, backendTag)
+	qp.Add("fallback", "en-US")
+	return qp
 }
 
-func writeLocoPO(baseDir string, zipData io.ReadCloser) error {
+// writeLocoPO writes the PO files in the export archive under baseDir, leaving out the locales the gate holds back
+func writeLocoPO(baseDir string, zipData io.ReadCloser, gate *completionGate) error {
 	body, err := io.ReadAll(zipData)
 	if err != nil {
 		return fmt.Errorf("error reading all response bytes: %v", err)
@@ -52,6 +107,9 @@ func writeLocoPO(baseDir string, zipData io.ReadCloser) error {
 		if ext != ".po" {
 			continue
 		}
+		if !gate.allows(zipLocale(zipPath)) {
+			continue
+		}
 
 		poDir, err := outputFromZip(baseDir, zipPath, zipFile)
 		if err != nil {
@@ -79,12 +137,16 @@ func writeLocoPO(baseDir string, zipData io.ReadCloser) error {
 			if err != nil {
 				slog.Error("error creating dup output file for",
 					slog.String("loc", l.String()), slog.Any("err", err))
+				run.failed(newPath, err)
 				continue
 			}
 			_, err = writeZipFile(zipFile, poFile)
 			if err != nil && err != io.EOF {
 				slog.Error("error creating dup output file for",
 					slog.String("loc", l.String()), slog.Any("err", err))
+				run.failed(poFile.Name(), err)
+			} else {
+				run.written(poFile.Name())
 			}
 			poFile.Close()
 		}
@@ -93,6 +155,15 @@ func writeLocoPO(baseDir string, zipData io.ReadCloser) error {
 	return readErr
 }
 
+// zipLocale is the locale directory in an archive path, e.g. de_DE in hourglass-po-archive/po/de_DE/LC_MESSAGES/
+func zipLocale(zipPath string) string {
+	components := strings.Split(zipPath, "/")
+	if len(components) < 3 {
+		return ""
+	}
+	return components[2]
+}
+
 func localeFromPath(dir string) string {
 	parts := strings.Split(dir, "/")
 	if len(parts) < 2 {
@@ -114,6 +185,7 @@ func normalizeScript(in string) string {
 func outputFromZip(baseDir, zipPath string, zipFile *zip.File) (poDir string, err error) {
 	poFile, poDir, err := createOutputFile(baseDir, zipPath, true)
 	if err != nil {
+		run.failed(zipPath, err)
 		return
 	}
 	if poFile == nil {
@@ -126,8 +198,10 @@ func outputFromZip(baseDir, zipPath string, zipFile *zip.File) (poDir string, er
 	if err != nil && err != io.EOF {
 		slog.Error("error writing contents to po file",
 			slog.String("file", poFile.Name()), slog.Any("err", err))
+		run.failed(poFile.Name(), err)
 		return
 	}
+	run.written(poFile.Name())
 
 	return poDir, nil
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const locoAuthVerifyURL = locoBaseURL + "/auth/verify"
+
+// LocoProject is the project an API key belongs to
+type LocoProject struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type locoAuth struct {
+	Project LocoProject `json:"project"`
+}
+
+// Slug is the project's name in its loco URL, which loco also uses to name exports, e.g. the hourglass in
+// hourglass-de_DE.yml
+func (p LocoProject) Slug() string {
+	if u, err := url.Parse(p.URL); err == nil {
+		if slug := path.Base(strings.TrimSuffix(u.Path, "/")); slug != "." && slug != "/" {
+			return slug
+		}
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(p.Name)), " ", "-")
+}
+
+// getLocoProject asks loco which project the API key is for
+func getLocoProject(apiKey string) (LocoProject, error) {
+	resp, err := locoRequest(apiKey, locoAuthVerifyURL, nil)
+	if err != nil {
+		return LocoProject{}, err
+	}
+	defer resp.Body.Close()
+
+	var auth locoAuth
+	err = json.NewDecoder(resp.Body).Decode(&auth)
+	if err != nil {
+		return LocoProject{}, err
+	}
+	if auth.Project.URL == "" && auth.Project.Name == "" {
+		return LocoProject{}, fmt.Errorf("loco didn't say which project the API key is for")
+	}
+	return auth.Project, nil
+}
+
+// forEachProject runs an export for every project in the config, each with its own API key found through c and a
+// directory of its own under dir. without projects in the config it runs once for the project apiKey belongs to,
+// straight into dir. the project name comes from the config, or otherwise from loco.
+func forEachProject(apiKey, dir string, projects []ProjectConfig, c credentialOptions,
+	export func(apiKey, project, dir string) error) error {
+	if len(projects) == 0 {
+		project, err := getLocoProject(apiKey)
+		if err != nil {
+			return err
+		}
+		return export(apiKey, project.Slug(), dir)
+	}
+
+	for _, p := range projects {
+		projectKey, source, err := c.projectKey(p)
+		if err != nil {
+			return err
+		}
+		slog.Debug("project api key", slog.String("project", p.describe()), slog.String("from", source))
+		name := p.Name
+		if name == "" {
+			project, err := getLocoProject(projectKey)
+			if err != nil {
+				return err
+			}
+			name = project.Slug()
+		}
+		projectDir := p.Dir
+		if projectDir == "" {
+			projectDir = name
+		}
+		projectDir = filepath.Join(dir, projectDir)
+		err = os.MkdirAll(projectDir, 0777)
+		if err != nil {
+			return err
+		}
+		slog.Info("exporting project", slog.String("project", name), slog.String("dir", projectDir))
+		err = export(projectKey, name, projectDir)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", name, err)
+		}
+	}
+	return nil
+}
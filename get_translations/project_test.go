@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLocoProjectSlug(t *testing.T) {
+	tests := []struct {
+		name    string
+		project LocoProject
+		want    string
+	}{
+		{name: "URL", project: LocoProject{Name: "Hourglass App", URL: "https://localise.biz/razor/hourglass"},
+			want: "hourglass"},
+		{name: "TrailingSlash", project: LocoProject{URL: "https://localise.biz/razor/web-app/"}, want: "web-app"},
+		{name: "Name", project: LocoProject{Name: "Web App"}, want: "web-app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.project.Slug(); got != tt.want {
+				t.Errorf("Slug() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForEachProject(t *testing.T) {
+	t.Setenv("TEST_LOCO_KEY_WEB", "web-key")
+	dir := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "mobile-key")
+	if err := os.WriteFile(keyFile, []byte("mobile-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	projects := []ProjectConfig{
+		{APIKeyVar: "TEST_LOCO_KEY_WEB", Name: "web"},
+		{APIKeyFile: keyFile, Name: "mobile", Dir: "apps/mobile"},
+		{Name: "admin"},
+	}
+	c := credentialOptions{Helper: "printf %s-%s-key"}
+
+	type call struct{ apiKey, project, dir string }
+	var calls []call
+	err := forEachProject("default-key", dir, projects, c, func(apiKey, project, dir string) error {
+		calls = append(calls, call{apiKey, project, dir})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []call{
+		{"web-key", "web", filepath.Join(dir, "web")},
+		{"mobile-key", "mobile", filepath.Join(dir, "apps", "mobile")},
+		{"read-admin-key", "admin", filepath.Join(dir, "admin")},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	for _, c := range calls {
+		if !isValidDir(c.dir) {
+			t.Errorf("%s wasn't created", c.dir)
+		}
+	}
+
+	err = forEachProject("default-key", dir, projects, credentialOptions{},
+		func(apiKey, project, dir string) error { return nil })
+	if !errors.Is(err, errAuth) {
+		t.Errorf("forEachProject() without a project's API key = %v, want %v", err, errAuth)
+	}
+}
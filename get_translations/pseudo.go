@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// accented and about a third longer, to find hard coded strings and truncation
+	pseudoAccented = "en-XA"
+	// right to left, to find layouts that don't mirror
+	pseudoBidi = "ar-XB"
+
+	// unicode bidi controls
+	rlm = "\u200f"
+	rlo = "\u202e"
+	pdf = "\u202c"
+)
+
+var pseudoLocales = []string{pseudoAccented, pseudoBidi}
+
+// every placeholder syntax that is passed through loco: python named parameters, i18next and ICU arguments,
+// stringsdict variables, and positional and plain printf (including the iOS %@)
+const placeholderPattern = `%\(\w+\)[-+ #0]*\d*(?:\.\d+)?[sdifeEgGxX]` +
+	`|\{\{[^{}]*\}\}` +
+	`|\{\w+(?:,[^{}]*)?\}` +
+	`|%#@\w+@` +
+	`|%\d+\$[-+ #0]*\d*(?:\.\d+)?(?:ll|l|h)?[@a-zA-Z]` +
+	`|%[-+ #0]*\d*(?:\.\d+)?(?:ll|l|h)?[@dDiuUxXoOfeEgGcCsSpaA%]`
+
+var placeholderRegex = regexp.MustCompile(placeholderPattern)
+
+// placeholders, and the markup that has to survive as well: backslash escapes, XML entities and tags
+var pseudoProtected = regexp.MustCompile(placeholderPattern +
+	`|\\(?:u[0-9a-fA-F]{4}|.)` +
+	`|&(?:#\d+|#x[0-9a-fA-F]+|\w+);` +
+	`|<[^>]*>`)
+
+// a string that is only a reference to another android resource has to stay one
+var androidReference = regexp.MustCompile(`^@(?:android:)?string/\w+$`)
+
+var pseudoAccents = func() map[rune]rune {
+	plain := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	accented := []rune("åƀçđéƒĝĥîĵķļɱñöþǫŕšţûṽŵẋýžÅßÇĐÉƑĜĤÎĴĶĻṀÑÖÞǪŔŠŢÛṼŴẊÝŽ")
+	accents := make(map[rune]rune, len(plain))
+	for i, r := range plain {
+		accents[r] = accented[i]
+	}
+	return accents
+}()
+
+// words used to lengthen en-XA strings, the way android's pseudo-locales do
+var pseudoPadding = strings.Fields("one two three four five six seven eight nine ten eleven twelve")
+
+// validatePseudoLocales checks that every requested locale is one we can generate
+func validatePseudoLocales(requested []string) error {
+	for _, locale := range requested {
+		if locale != pseudoAccented && locale != pseudoBidi {
+			return fmt.Errorf("unknown pseudo-locale %s: use %s", locale, strings.Join(pseudoLocales, " or "))
+		}
+	}
+	return nil
+}
+
+// pseudolocalize transforms a source string for the pseudo-locale, leaving placeholders and markup untouched
+func pseudolocalize(locale, s string) string {
+	if s == "" || androidReference.MatchString(s) {
+		return s
+	}
+	var b strings.Builder
+	letters := 0
+	last := 0
+	transform := func(text string) {
+		for _, r := range text {
+			if unicode.IsLetter(r) {
+				letters++
+			}
+		}
+		if locale == pseudoBidi {
+			b.WriteString(bidiWords(text))
+			return
+		}
+		b.WriteString(strings.Map(func(r rune) rune {
+			if accented, ok := pseudoAccents[r]; ok {
+				return accented
+			}
+			return r
+		}, text))
+	}
+
+	if locale == pseudoAccented {
+		b.WriteString("[")
+	}
+	for _, loc := range pseudoProtected.FindAllStringIndex(s, -1) {
+		transform(s[last:loc[0]])
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	transform(s[last:])
+	if locale == pseudoAccented {
+		b.WriteString(pseudoExpansion(letters))
+		b.WriteString("]")
+	}
+	return b.String()
+}
+
+// pseudoExpansion returns padding that makes a string with letters letters about 30% longer
+func pseudoExpansion(letters int) string {
+	target := (letters*3 + 9) / 10
+	if target == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i := 0; utf8.RuneCountInString(b.String()) < target; i++ {
+		b.WriteString(" ")
+		b.WriteString(pseudoPadding[i%len(pseudoPadding)])
+	}
+	return b.String()
+}
+
+// bidiWords forces each word right to left
+func bidiWords(text string) string {
+	var b strings.Builder
+	word := make([]rune, 0)
+	flush := func() {
+		if len(word) > 0 {
+			b.WriteString(rlm + rlo + string(word) + pdf + rlm)
+			word = word[:0]
+		}
+	}
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			flush()
+			b.WriteRune(r)
+			continue
+		}
+		word = append(word, r)
+	}
+	flush()
+	return b.String()
+}
+
+// pseudolocalizeValues transforms every string in decoded json, e.g. an i18next namespace
+func pseudolocalizeValues(locale string, data any) any {
+	switch v := data.(type) {
+	case string:
+		return pseudolocalize(locale, v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[key] = pseudolocalizeValues(locale, value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = pseudolocalizeValues(locale, value)
+		}
+		return out
+	}
+	return data
+}
+
+// the contents of each string and plural or array item in a strings.xml
+var androidStringContent = regexp.MustCompile(`(?s)(<(?:string|item)(?:\s[^>]*[^/>])?>)(.*?)(</(?:string|item)>)`)
+
+// pseudolocalizeAndroid transforms the strings in a strings.xml
+func pseudolocalizeAndroid(locale string, data []byte) []byte {
+	return androidStringContent.ReplaceAllFunc(data, func(element []byte) []byte {
+		parts := androidStringContent.FindSubmatch(element)
+		content := string(parts[2])
+		if strings.HasPrefix(content, "<![CDATA[") {
+			return element
+		}
+		return []byte(string(parts[1]) + pseudolocalize(locale, content) + string(parts[3]))
+	})
+}
+
+// pseudolocalizePO transforms the msgstrs of a PO file. the strings are transformed while still escaped, since the
+// escapes are protected like placeholders; continuation lines are joined so each string is bracketed once.
+func pseudolocalizePO(locale string, data []byte) []byte {
+	out := make([]string, 0)
+	var msgid strings.Builder
+	inMsgid := false
+	// the msgstr being collected. the header's is passed through line by line instead.
+	keyword := ""
+	pieces := make([]string, 0)
+	header := false
+	flush := func() {
+		if keyword != "" {
+			out = append(out, fmt.Sprintf(`%s "%s"`, keyword, pseudolocalize(locale, strings.Join(pieces, ""))))
+			keyword = ""
+			pieces = pieces[:0]
+		}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, `"`) {
+			switch {
+			case keyword != "":
+				pieces = append(pieces, poQuoted(trimmed))
+				continue
+			case inMsgid:
+				msgid.WriteString(poQuoted(trimmed))
+			case header && strings.HasPrefix(trimmed, `"Language:`):
+				line = fmt.Sprintf(`"Language: %s\n"`, strings.ReplaceAll(locale, "-", "_"))
+			}
+			out = append(out, line)
+			continue
+		}
+
+		flush()
+		word, rest, _ := strings.Cut(trimmed, " ")
+		inMsgid = false
+		switch {
+		case word == "msgid":
+			msgid.Reset()
+			msgid.WriteString(poQuoted(rest))
+			inMsgid = true
+			header = false
+		case word == "msgstr" || strings.HasPrefix(word, "msgstr["):
+			header = msgid.Len() == 0
+			if !header {
+				keyword = word
+				pieces = append(pieces, poQuoted(rest))
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	flush()
+	return []byte(strings.Join(out, "\n"))
+}
+
+// poQuoted returns what is between the quotes of a PO string, still escaped
+func poQuoted(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
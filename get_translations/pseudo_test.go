@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPseudolocalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		in     string
+		want   string
+	}{
+		{name: "Accented", locale: pseudoAccented, in: "Hello", want: "[Ĥéļļö one]"},
+		{name: "Python", locale: pseudoAccented, in: "Drop %(filename)s here",
+			want: "[Đŕöþ %(filename)s ĥéŕé one]"},
+		{name: "I18next", locale: pseudoAccented, in: "Hi {{name}}", want: "[Ĥî {{name}} one]"},
+		{name: "Positional", locale: pseudoAccented, in: "%1$s of %2$d", want: "[%1$s öƒ %2$d one]"},
+		{name: "IOS", locale: pseudoAccented, in: "%@ and %lld", want: "[%@ åñđ %lld one]"},
+		{name: "Stringsdict", locale: pseudoAccented, in: "%#@count@", want: "[%#@count@]"},
+		{name: "Escapes", locale: pseudoAccented, in: `it\'s\nok`, want: `[îţ\'š\nöķ one]`},
+		{name: "Markup", locale: pseudoAccented, in: "<b>a</b> &amp;", want: "[<b>å</b> &amp; one]"},
+		{name: "Reference", locale: pseudoAccented, in: "@string/app_name", want: "@string/app_name"},
+		{name: "Bidi", locale: pseudoBidi, in: "Hi %(name)s",
+			want: rlm + rlo + "Hi" + pdf + rlm + " %(name)s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pseudolocalize(tt.locale, tt.in); got != tt.want {
+				t.Errorf("pseudolocalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPseudolocalizeAndroid(t *testing.T) {
+	in := `<resources>
+    <string name="hello">Hello</string>
+    <string name="empty"/>
+    <string-array name="days"><item>Mon</item></string-array>
+    <plurals name="files"><item quantity="other">%d files</item></plurals>
+</resources>`
+	want := `<resources>
+    <string name="hello">[Ĥéļļö one]</string>
+    <string name="empty"/>
+    <string-array name="days"><item>[Ṁöñ one]</item></string-array>
+    <plurals name="files"><item quantity="other">[%d ƒîļéš one]</item></plurals>
+</resources>`
+	if got := string(pseudolocalizeAndroid(pseudoAccented, []byte(in))); got != want {
+		t.Errorf("pseudolocalizeAndroid() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPseudolocalizePO(t *testing.T) {
+	in := `msgid ""
+msgstr ""
+"Language: en_US\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "greeting"
+msgstr ""
+"Hello "
+"%(name)s"
+
+msgid "file"
+msgid_plural "files"
+msgstr[0] "File"
+msgstr[1] "Files"
+`
+	got := string(pseudolocalizePO(pseudoAccented, []byte(in)))
+	for _, want := range []string{
+		`"Language: en_XA\n"`,
+		`"Plural-Forms: nplurals=2; plural=(n != 1);\n"`,
+		`msgstr "[Ĥéļļö %(name)s one]"`,
+		`msgstr[1] "[Ƒîļéš one]"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+}
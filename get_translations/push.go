@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// #nosec G101 // this is not a credential
+	writeAPIKeyVar   = "LOCO_API_KEY"
+	locoAssetTagsURL = locoBaseURL + "/assets/%s/tags"
+	// how many references to list in a new asset's notes
+	maxNoteReferences = 5
+)
+
+type pushOptions struct {
+	// directories of go source, and the generated asset_ids.go whose constants they use
+	GoDirs     []string
+	AssetsFile string
+	// directories of javascript and typescript source using i18next
+	JSDirs   []string
+	POTFiles []string
+	Tags     []string
+	Notes    string
+	DryRun   bool
+}
+
+// pushAssets extracts the message IDs from source code and POT files and creates the ones that loco doesn't have yet.
+// the assets are listed with the read only key; creating them needs a key that allows writing.
+func pushAssets(apiKey, writeAPIKey string, opts pushOptions) error {
+	keys, err := extractKeys(opts)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no message IDs found")
+	}
+
+	existing, err := getAssets(apiKey, "")
+	if err != nil {
+		return err
+	}
+	missing := missingAssets(keys, existing)
+	slog.Info("extracted message IDs", slog.Int("found", len(keys)), slog.Int("missing", len(missing)))
+
+	if opts.DryRun {
+		writeDryRun(reportOut, missing, opts)
+		return nil
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if writeAPIKey == "" {
+		return fmt.Errorf("%w: creating assets needs a loco API key that allows writing in %s", errAuth, writeAPIKeyVar)
+	}
+
+	created := 0
+	for _, key := range missing {
+		err = createAsset(writeAPIKey, key, opts)
+		if err != nil {
+			slog.Error("failed to create asset", slog.String("id", key.ID), slog.Any("err", err))
+			continue
+		}
+		created++
+	}
+	slog.Info("created assets", slog.Int("count", created))
+	if created == 0 {
+		return fmt.Errorf("created none of %d assets", len(missing))
+	}
+	if created != len(missing) {
+		return fmt.Errorf("%w: created %d of %d assets", errPartial, created, len(missing))
+	}
+	return nil
+}
+
+func extractKeys(opts pushOptions) ([]extractedKey, error) {
+	c := newKeyCollector()
+	if len(opts.GoDirs) > 0 {
+		constants := make(map[string]string)
+		if opts.AssetsFile != "" {
+			var err error
+			constants, err = assetConstants(opts.AssetsFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, dir := range opts.GoDirs {
+			err := extractGoKeys(c, dir, constants, opts.AssetsFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, dir := range opts.JSDirs {
+		err := extractJSKeys(c, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range opts.POTFiles {
+		err := extractPOTKeys(c, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.sorted(), nil
+}
+
+func missingAssets(keys []extractedKey, existing []LocoAsset) []extractedKey {
+	ids := make(map[string]bool, len(existing))
+	for _, asset := range existing {
+		ids[asset.ID] = true
+	}
+	missing := make([]extractedKey, 0)
+	for _, key := range keys {
+		if !ids[key.ID] {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// assetNotes is the notes text for a new asset: the notes from the command line and where the ID is used
+func assetNotes(key extractedKey, notes string) string {
+	lines := make([]string, 0, maxNoteReferences+2)
+	if notes != "" {
+		lines = append(lines, notes)
+	}
+	refs := key.References
+	if len(refs) > maxNoteReferences {
+		refs = refs[:maxNoteReferences]
+	}
+	if len(refs) > 0 {
+		lines = append(lines, "Used in: "+strings.Join(refs, ", "))
+	}
+	if len(key.References) > len(refs) {
+		lines = append(lines, fmt.Sprintf("and %d more", len(key.References)-len(refs)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeDryRun(out io.Writer, missing []extractedKey, opts pushOptions) {
+	if len(missing) == 0 {
+		fmt.Fprintln(out, "no new assets")
+		return
+	}
+	fmt.Fprintf(out, "would create %d assets", len(missing))
+	if len(opts.Tags) > 0 {
+		fmt.Fprintf(out, " tagged %s", strings.Join(opts.Tags, ", "))
+	}
+	fmt.Fprintln(out, ":")
+	for _, key := range missing {
+		fmt.Fprintf(out, "  %s\n", key.ID)
+		for _, line := range strings.Split(assetNotes(key, opts.Notes), "\n") {
+			if line != "" {
+				fmt.Fprintf(out, "      %s\n", line)
+			}
+		}
+	}
+}
+
+func createAsset(apiKey string, key extractedKey, opts pushOptions) error {
+	form := url.Values{}
+	form.Set("id", key.ID)
+	form.Set("text", key.ID)
+	if notes := assetNotes(key, opts.Notes); notes != "" {
+		form.Set("notes", notes)
+	}
+	resp, err := locoWriteForm(apiKey, locoAssetsURL, http.MethodPost, form)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	tagsURL := fmt.Sprintf(locoAssetTagsURL, url.PathEscape(key.ID))
+	for _, tag := range opts.Tags {
+		resp, err = locoWriteForm(apiKey, tagsURL, http.MethodPost, url.Values{"name": {tag}})
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("tagging with %s: %w", tag, err)
+		}
+	}
+	slog.Info("created asset", slog.String("id", key.ID))
+	return nil
+}
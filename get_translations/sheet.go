@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sheetExtCSV  = ".csv"
+	sheetExtXLSX = ".xlsx"
+	// the file name of the sheet with a column per locale
+	sheetWideName = "translations"
+
+	sheetColumnID          = "id"
+	sheetColumnSource      = "source"
+	sheetColumnTranslation = "translation"
+	sheetColumnStatus      = "status"
+	sheetColumnNotes       = "notes"
+	// the status columns of the wide sheet are named after the locale with this suffix
+	sheetStatusSuffix = " status"
+
+	sheetStatusUntranslated = "untranslated"
+	sheetStatusTranslated   = "translated"
+	sheetStatusFlagged      = "flagged"
+
+	// excel only reads a CSV as UTF-8 when it starts with a byte order mark
+	utf8BOM = "\ufeff"
+)
+
+type sheetOptions struct {
+	// only export assets with this tag
+	Tag string
+	// locales to export. every locale but the source when empty
+	Locales []string
+	// one sheet with a column per locale instead of a sheet per locale
+	Wide bool
+	// also write an XLSX workbook next to each CSV
+	XLSX bool
+}
+
+type sheetImportOptions struct {
+	// show the changes without asking or uploading
+	DryRun bool
+	// upload without asking
+	Yes bool
+}
+
+// sheetEdit is a translation as it is in a sheet
+type sheetEdit struct {
+	ID     string
+	Locale string
+	Source string
+	Text   string
+}
+
+// sheetChange is an edit that differs from the translation in loco
+type sheetChange struct {
+	sheetEdit
+	Old string
+}
+
+// exportSheets writes the translations out as spreadsheets for reviewers: a sheet per locale with the asset ID,
+// source, translation, status and notes, or with opts.Wide one sheet with a translation and status column per locale
+func exportSheets(apiKey, dir string, opts sheetOptions) error {
+	if !isValidDir(dir) {
+		return fmt.Errorf("invalid directory: %s", dir)
+	}
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco")
+	}
+	assets, err := getAssets(apiKey, opts.Tag)
+	if err != nil {
+		return err
+	}
+	translations, err := fetchAllTranslations(apiKey, assets)
+	if err != nil {
+		return err
+	}
+
+	targets := make([]string, 0, len(allLocales))
+	for _, loc := range allLocales {
+		if loc.Source || (len(opts.Locales) > 0 && !containsLocale(opts.Locales, loc.Code)) {
+			continue
+		}
+		targets = append(targets, loc.Code)
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no locales to export")
+	}
+
+	if opts.Wide {
+		return writeSheet(dir, sheetWideName, wideSheetRows(assets, translations, source.Code, targets), opts.XLSX)
+	}
+	for _, target := range targets {
+		err = writeSheet(dir, target, localeSheetRows(xliffUnits(assets, translations, source.Code, target)), opts.XLSX)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sheetStatus(unit xliffUnit) string {
+	switch {
+	case unit.Flagged:
+		return sheetStatusFlagged
+	case unit.Target != "":
+		return sheetStatusTranslated
+	}
+	return sheetStatusUntranslated
+}
+
+func localeSheetRows(units []xliffUnit) [][]string {
+	rows := [][]string{{sheetColumnID, sheetColumnSource, sheetColumnTranslation, sheetColumnStatus, sheetColumnNotes}}
+	for _, unit := range units {
+		rows = append(rows, []string{unit.ID, unit.Source, unit.Target, sheetStatus(unit), unit.Notes})
+	}
+	return rows
+}
+
+func wideSheetRows(assets []LocoAsset, translations map[string][]LocoTranslation, sourceCode string,
+	targets []string) [][]string {
+	header := []string{sheetColumnID, sheetColumnSource, sheetColumnNotes}
+	// xliffUnits leaves out the same assets, the ones without a source, for every locale, so the units line up
+	units := make([][]xliffUnit, len(targets))
+	for i, target := range targets {
+		header = append(header, target, target+sheetStatusSuffix)
+		units[i] = xliffUnits(assets, translations, sourceCode, target)
+	}
+
+	rows := [][]string{header}
+	for j, unit := range units[0] {
+		row := []string{unit.ID, unit.Source, unit.Notes}
+		for i := range targets {
+			row = append(row, units[i][j].Target, sheetStatus(units[i][j]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeSheet(dir, name string, rows [][]string, withXLSX bool) error {
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+	path := filepath.Join(dir, name+sheetExtCSV)
+	w := csv.NewWriter(&buf)
+	err := w.WriteAll(rows)
+	if err != nil {
+		return run.record(path, err)
+	}
+	err = run.record(path, os.WriteFile(path, buf.Bytes(), 0666))
+	if err != nil {
+		return err
+	}
+	slog.Info("wrote sheet", slog.String("file", path), slog.Int("rows", len(rows)-1))
+	if !withXLSX {
+		return nil
+	}
+
+	path = filepath.Join(dir, name+sheetExtXLSX)
+	f, err := os.Create(path)
+	if err != nil {
+		return run.record(path, err)
+	}
+	err = writeXLSX(f, rows)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err = run.record(path, err); err != nil {
+		return err
+	}
+	slog.Info("wrote sheet", slog.String("file", path), slog.Int("rows", len(rows)-1))
+	return nil
+}
+
+// importSheets uploads the translations that reviewers changed in exported sheets. the sheets are compared with loco
+// using the read only key, the changes are listed and, once confirmed, posted with the key that allows writing.
+func importSheets(apiKey, writeAPIKey string, paths []string, opts sheetImportOptions) error {
+	if writeAPIKey == "" && !opts.DryRun {
+		return fmt.Errorf("%w: importing needs a loco API key that allows writing in %s", errAuth, writeAPIKeyVar)
+	}
+	edits := make([]sheetEdit, 0)
+	for _, path := range paths {
+		rows, err := readSheet(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fileEdits, err := sheetEdits(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), rows)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		edits = append(edits, fileEdits...)
+	}
+
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco")
+	}
+	err = checkSheetLocales(edits, allLocales)
+	if err != nil {
+		return err
+	}
+
+	assets, err := getAssets(apiKey, "")
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		ids[asset.ID] = true
+	}
+	// only the assets in the sheets are needed
+	edited := make([]LocoAsset, 0)
+	seen := make(map[string]bool)
+	for _, edit := range edits {
+		if seen[edit.ID] {
+			continue
+		}
+		seen[edit.ID] = true
+		if !ids[edit.ID] {
+			slog.Warn("not an asset in loco", slog.String("id", edit.ID))
+			continue
+		}
+		edited = append(edited, LocoAsset{ID: edit.ID})
+	}
+	translations, err := fetchAllTranslations(apiKey, edited)
+	if err != nil {
+		return err
+	}
+
+	changes, invalid := diffSheet(edits, translations, source.Code)
+	writeSheetChanges(reportOut, changes)
+	if len(changes) == 0 || opts.DryRun {
+		return importResult(invalid, 0)
+	}
+	if !opts.Yes && !confirm(os.Stdin, reportOut, fmt.Sprintf("upload %d changed translations?", len(changes))) {
+		return fmt.Errorf("import cancelled")
+	}
+
+	failed := 0
+	for _, change := range changes {
+		transURL := fmt.Sprintf(locoPostTranslationURL, url.PathEscape(change.ID), change.Locale)
+		resp, postErr := locoWrite(writeAPIKey, transURL, http.MethodPost, []byte(change.Text))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if postErr != nil {
+			slog.Error("failed to write translation", slog.String("id", change.ID),
+				slog.String("locale", change.Locale), slog.Any("err", postErr))
+			failed++
+		}
+	}
+	slog.Info("imported", slog.Int("translations", len(changes)-failed), slog.Int("invalid", invalid),
+		slog.Int("failed", failed))
+	return importResult(invalid, failed)
+}
+
+func readSheet(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), sheetExtXLSX) {
+		return readXLSX(data)
+	}
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte(utf8BOM))))
+	// spreadsheet apps drop trailing empty cells
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+// sheetEdits reads the translations in a sheet. a sheet with a translation column is for the locale it is named
+// after; otherwise every column that isn't the ID, source, notes or a status is a locale.
+func sheetEdits(name string, rows [][]string) ([]sheetEdit, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty sheet")
+	}
+	idCol, sourceCol := -1, -1
+	locales := make(map[int]string)
+	translationCol := -1
+	for i, column := range rows[0] {
+		column = strings.TrimSpace(column)
+		switch {
+		case column == sheetColumnID:
+			idCol = i
+		case column == sheetColumnSource:
+			sourceCol = i
+		case column == sheetColumnTranslation:
+			translationCol = i
+		case column == sheetColumnStatus || column == sheetColumnNotes || column == "" ||
+			strings.HasSuffix(column, sheetStatusSuffix):
+		default:
+			locales[i] = column
+		}
+	}
+	if idCol < 0 {
+		return nil, fmt.Errorf("no %s column", sheetColumnID)
+	}
+	if translationCol >= 0 {
+		locales = map[int]string{translationCol: name}
+	}
+	if len(locales) == 0 {
+		return nil, fmt.Errorf("no translation or locale columns")
+	}
+
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		// excel saves line breaks in cells as CRLF
+		return strings.ReplaceAll(row[i], "\r\n", "\n")
+	}
+	edits := make([]sheetEdit, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		id := strings.TrimSpace(cell(row, idCol))
+		if id == "" {
+			continue
+		}
+		for i, locale := range locales {
+			edits = append(edits, sheetEdit{ID: id, Locale: locale, Source: cell(row, sourceCol), Text: cell(row, i)})
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].ID != edits[j].ID {
+			return edits[i].ID < edits[j].ID
+		}
+		return edits[i].Locale < edits[j].Locale
+	})
+	return edits, nil
+}
+
+// checkSheetLocales fails when a sheet has a locale that isn't in loco, which happens when the sheet was renamed. its
+// edits would otherwise be left out without a word.
+func checkSheetLocales(edits []sheetEdit, allLocales []LocoLocale) error {
+	known := make(map[string]bool, len(allLocales))
+	for _, loc := range allLocales {
+		known[completionKey(loc.Code)] = true
+	}
+	unknown := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, edit := range edits {
+		if !known[completionKey(edit.Locale)] && !seen[edit.Locale] {
+			seen[edit.Locale] = true
+			unknown = append(unknown, edit.Locale)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: not a locale in loco: %s; the sheets are named after the locale they're for",
+			errValidation, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// diffSheet returns the edits that change a translation in loco, and how many were left out because their
+// placeholders don't match the source. the source is loco's, in the locale sourceCode, not the sheet's, which the
+// reviewer could have changed. emptied cells are left alone rather than deleting translations.
+func diffSheet(edits []sheetEdit, translations map[string][]LocoTranslation, sourceCode string) ([]sheetChange, int) {
+	sources := sourceTexts(translations, sourceCode)
+	changes := make([]sheetChange, 0)
+	invalid := 0
+	for _, edit := range edits {
+		if edit.Text == "" {
+			continue
+		}
+		var current *LocoTranslation
+		for i, translation := range translations[edit.ID] {
+			if completionKey(translation.Locale.Code) == completionKey(edit.Locale) {
+				current = &translations[edit.ID][i]
+				break
+			}
+		}
+		if current == nil {
+			// not an asset in loco, which was already warned about; the locales were checked
+			continue
+		}
+		old := ""
+		if current.Translated {
+			old = current.Translation
+		}
+		if edit.Text == old {
+			continue
+		}
+		if problem := placeholderMismatch(sources[edit.ID], edit.Text); problem != "" {
+			slog.Warn("placeholders don't match the source", slog.String("id", edit.ID),
+				slog.String("locale", edit.Locale), slog.String("problem", problem))
+			invalid++
+			continue
+		}
+		edit.Locale = current.Locale.Code
+		changes = append(changes, sheetChange{sheetEdit: edit, Old: old})
+	}
+	return changes, invalid
+}
+
+func writeSheetChanges(out io.Writer, changes []sheetChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "no changes")
+		return
+	}
+	perLocale := make(map[string]int)
+	for _, change := range changes {
+		perLocale[change.Locale]++
+		fmt.Fprintf(out, "%s [%s]\n", change.ID, change.Locale)
+		fmt.Fprintf(out, "  - %q\n  + %q\n", change.Old, change.Text)
+	}
+	locales := make([]string, 0, len(perLocale))
+	for locale := range perLocale {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	summary := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		summary = append(summary, fmt.Sprintf("%s %d", locale, perLocale[locale]))
+	}
+	fmt.Fprintf(out, "%d changed translations: %s\n", len(changes), strings.Join(summary, ", "))
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{sheetColumnID, sheetColumnSource, "de-DE", "de-DE" + sheetStatusSuffix},
+		{"save", "Save & close", "Speichern & schließen", sheetStatusTranslated},
+		{"multi", "two\nlines ", "", sheetStatusUntranslated},
+	}
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readXLSX(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("readXLSX() = %q, want %q", got, rows)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{ref: "A1", want: 0},
+		{ref: "C12", want: 2},
+		{ref: "AA3", want: 26},
+	}
+	for _, tt := range tests {
+		if got, _ := xlsxColumn(tt.ref); got != tt.want {
+			t.Errorf("xlsxColumn(%s) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestSheetEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		rows    [][]string
+		want    []sheetEdit
+		wantErr bool
+	}{
+		{name: "Locale", file: "de-DE", rows: [][]string{
+			{sheetColumnID, sheetColumnSource, sheetColumnTranslation, sheetColumnStatus, sheetColumnNotes},
+			{"save", "Save", "Speichern", sheetStatusTranslated},
+			{"", "stray"},
+		}, want: []sheetEdit{{ID: "save", Locale: "de-DE", Source: "Save", Text: "Speichern"}}},
+		{name: "Wide", file: sheetWideName, rows: [][]string{
+			{sheetColumnID, sheetColumnSource, sheetColumnNotes, "de-DE", "de-DE status", "fr-FR", "fr-FR status"},
+			{"save", "Save", "", "Speichern", sheetStatusTranslated, "Enregistrer\r\nà nouveau"},
+		}, want: []sheetEdit{
+			{ID: "save", Locale: "de-DE", Source: "Save", Text: "Speichern"},
+			{ID: "save", Locale: "fr-FR", Source: "Save", Text: "Enregistrer\nà nouveau"},
+		}},
+		{name: "NoID", file: "de-DE", rows: [][]string{{sheetColumnSource, sheetColumnTranslation}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sheetEdits(tt.file, tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sheetEdits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sheetEdits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffSheet(t *testing.T) {
+	translation := func(locale, text string, translated bool) LocoTranslation {
+		return LocoTranslation{LocoTranslationBase: LocoTranslationBase{Translation: text, Translated: translated},
+			Locale: LocoLocale{Code: locale}}
+	}
+	translations := map[string][]LocoTranslation{
+		"save": {translation("en-US", "Save", true), translation("de-DE", "Speichern", true),
+			translation("fr-FR", "Save", false)},
+		"hello": {translation("en-US", "Hi %(name)s", true), translation("de-DE", "Hallo %(name)s", true)},
+		"bye":   {translation("en-US", "Bye %(name)s", true), translation("de-DE", "Tschüss %(name)s", true)},
+	}
+	edits := []sheetEdit{
+		// the reviewer changed the source cell to match; loco's source is what counts
+		{ID: "hello", Locale: "de-DE", Source: "Hi %(nom)s", Text: "Hallo %(nom)s"},
+		// and emptied it here
+		{ID: "bye", Locale: "de-DE", Text: "Tschüss"},
+		{ID: "save", Locale: "de-DE", Source: "Save", Text: "Speichern"},
+		{ID: "save", Locale: "de_DE", Source: "Save", Text: ""},
+		{ID: "save", Locale: "fr_FR", Source: "Save", Text: "Enregistrer"},
+		{ID: "unknown", Locale: "de-DE", Text: "Unbekannt"},
+	}
+	changes, invalid := diffSheet(edits, translations, "en-US")
+	want := []sheetChange{
+		{sheetEdit: sheetEdit{ID: "save", Locale: "fr-FR", Source: "Save", Text: "Enregistrer"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("diffSheet() = %+v, want %+v", changes, want)
+	}
+	if invalid != 2 {
+		t.Errorf("invalid = %d, want 2", invalid)
+	}
+}
+
+func TestCheckSheetLocales(t *testing.T) {
+	allLocales := []LocoLocale{{Code: "en-US", Source: true}, {Code: "de-DE"}, {Code: "fr-FR"}}
+	edits := []sheetEdit{{ID: "save", Locale: "de_DE"}, {ID: "save", Locale: "fr-FR"}}
+	if err := checkSheetLocales(edits, allLocales); err != nil {
+		t.Errorf("checkSheetLocales() = %v", err)
+	}
+
+	// a sheet renamed to something that isn't a locale
+	edits = append(edits, sheetEdit{ID: "save", Locale: "translations-final"},
+		sheetEdit{ID: "bye", Locale: "translations-final"})
+	err := checkSheetLocales(edits, allLocales)
+	if !errors.Is(err, errValidation) || !strings.Contains(err.Error(), "translations-final") {
+		t.Errorf("checkSheetLocales() = %v, want a validation error naming translations-final", err)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	for answer, want := range map[string]bool{"y\n": true, "Yes\n": true, "\n": false, "n\n": false, "": false} {
+		var out bytes.Buffer
+		if got := confirm(strings.NewReader(answer), &out, "upload?"); got != want {
+			t.Errorf("confirm(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// the --output formats
+	outputText = "text"
+	outputJSON = "json"
+
+	fileWritten = "written"
+	fileSkipped = "skipped"
+	fileFailed  = "failed"
+	// an export that loco said hasn't changed since the last run; the path is the output directory
+	fileUnchanged = "unchanged"
+
+	runOK      = "ok"
+	runPartial = "partial"
+	runFailed  = "failed"
+	// the run succeeded without writing anything, since none of the exports changed
+	runUnchanged = "unchanged"
+	// what an unchanged run reports, in the text output and the json summary alike, so scripts can look for it on
+	// stdout either way
+	noChanges = "no changes"
+)
+
+// exit codes, so that scripts can tell what went wrong without parsing the logs
+const (
+	exitOK         = 0
+	exitFailure    = 1
+	exitAuth       = 2
+	exitNetwork    = 3
+	exitValidation = 4
+	exitPartial    = 5
+)
+
+var (
+	// loco rejected the API key, or it's missing
+	errAuth = errors.New("authentication failed")
+	// loco couldn't be reached or had a server error
+	errNetwork = errors.New("network failure")
+	// the translations failed a check, e.g. android resource issues or malformed ICU
+	errValidation = errors.New("validation failed")
+	// some of the work was done and some of it failed
+	errPartial = errors.New("partial success")
+)
+
+// reportOut is where the commands write their reports; with --output json it's stderr, leaving stdout to the summary
+var reportOut io.Writer = os.Stdout
+
+type fileResult struct {
+	Path   string `json:"path,omitempty"`
+	Locale string `json:"locale,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// runSummary collects what a run did for the --output json summary. the exporters add to it as they go; some of them
+// write files from several goroutines.
+type runSummary struct {
+	mu       sync.Mutex
+	Command  string       `json:"command"`
+	Status   string       `json:"status"`
+	ExitCode int          `json:"exit_code"`
+	Error    string       `json:"error,omitempty"`
+	Message  string       `json:"message,omitempty"`
+	Files    []fileResult `json:"files"`
+}
+
+var run = &runSummary{Files: make([]fileResult, 0)}
+
+func (s *runSummary) add(result fileResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Files = append(s.Files, result)
+}
+
+func (s *runSummary) written(path string) {
+	s.add(fileResult{Path: path, Status: fileWritten})
+}
+
+func (s *runSummary) skipped(path, reason string) {
+	s.add(fileResult{Path: path, Status: fileSkipped, Reason: reason})
+}
+
+// skippedLocale records a locale that wasn't written at all, e.g. one held back for being too little translated
+func (s *runSummary) skippedLocale(locale, reason string) {
+	s.add(fileResult{Locale: locale, Status: fileSkipped, Reason: reason})
+}
+
+// unchanged records an export that wasn't downloaded again since it hasn't changed
+func (s *runSummary) unchanged(dir string) {
+	s.add(fileResult{Path: dir, Status: fileUnchanged, Reason: "not modified since the last run"})
+}
+
+func (s *runSummary) failed(path string, err error) {
+	s.add(fileResult{Path: path, Status: fileFailed, Reason: err.Error()})
+}
+
+// record adds a file as written, or as failed when err isn't nil, and returns err
+func (s *runSummary) record(path string, err error) error {
+	if err != nil {
+		s.failed(path, err)
+	} else {
+		s.written(path)
+	}
+	return err
+}
+
+// reset forgets the files, for long running commands that only report their last round
+func (s *runSummary) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Files = make([]fileResult, 0)
+}
+
+// finish sets the outcome of the run from the command's error and the files that failed, and returns the exit code
+func (s *runSummary) finish(command string, err error) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failedFiles, writtenFiles, unchangedExports := 0, 0, 0
+	for _, f := range s.Files {
+		switch f.Status {
+		case fileFailed:
+			failedFiles++
+		case fileWritten:
+			writtenFiles++
+		case fileUnchanged:
+			unchangedExports++
+		}
+	}
+
+	s.Command = command
+	s.ExitCode = exitCode(err, failedFiles)
+	switch s.ExitCode {
+	case exitOK:
+		s.Status = runOK
+		if unchangedExports > 0 && writtenFiles == 0 {
+			s.Status = runUnchanged
+			s.Message = noChanges
+		}
+	case exitPartial:
+		s.Status = runPartial
+	default:
+		s.Status = runFailed
+	}
+	if err != nil {
+		s.Error = err.Error()
+	} else if failedFiles > 0 {
+		s.Error = fmt.Sprintf("%d files failed", failedFiles)
+	}
+	return s.ExitCode
+}
+
+func (s *runSummary) write(out io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// exitCode picks the exit code for a run's error. a run without an error that failed to write some files, which the
+// exporters log and carry on from, is a partial success.
+func exitCode(err error, failedFiles int) int {
+	switch {
+	case err == nil && failedFiles > 0:
+		return exitPartial
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errAuth):
+		return exitAuth
+	case errors.Is(err, errNetwork):
+		return exitNetwork
+	case errors.Is(err, errValidation):
+		return exitValidation
+	case errors.Is(err, errPartial):
+		return exitPartial
+	}
+	return exitFailure
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		failedFiles int
+		want        int
+	}{
+		{name: "OK", want: exitOK},
+		{name: "FailedFiles", failedFiles: 2, want: exitPartial},
+		{name: "Auth", err: statusError(http.StatusUnauthorized), want: exitAuth},
+		{name: "Forbidden", err: statusError(http.StatusForbidden), want: exitAuth},
+		{name: "ServerError", err: statusError(http.StatusBadGateway), want: exitNetwork},
+		{name: "RateLimited", err: statusError(http.StatusTooManyRequests), want: exitNetwork},
+		{name: "NotFound", err: statusError(http.StatusNotFound), want: exitFailure},
+		{name: "Wrapped", err: fmt.Errorf("project web: %w", statusError(http.StatusUnauthorized)), want: exitAuth},
+		{name: "Validation", err: importResult(1, 1), want: exitValidation},
+		{name: "Partial", err: importResult(0, 1), failedFiles: 1, want: exitPartial},
+		{name: "Other", err: errors.New("invalid directory"), failedFiles: 1, want: exitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err, tt.failedFiles); got != tt.want {
+				t.Errorf("exitCode(%v, %d) = %d, want %d", tt.err, tt.failedFiles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	s := &runSummary{Files: make([]fileResult, 0)}
+	s.written("out/de.json")
+	s.skippedLocale("fr-FR", "40.0% translated, below the minimum of 80.0%")
+	_ = s.record("out/it.json", errors.New("disk full"))
+	if code := s.finish("get_translations json", nil); code != exitPartial {
+		t.Errorf("finish() = %d, want %d", code, exitPartial)
+	}
+
+	var buf bytes.Buffer
+	if err := s.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"command":   "get_translations json",
+		"status":    runPartial,
+		"exit_code": float64(exitPartial),
+		"error":     "1 files failed",
+		"files": []any{
+			map[string]any{"path": "out/de.json", "status": fileWritten},
+			map[string]any{"locale": "fr-FR", "status": fileSkipped,
+				"reason": "40.0% translated, below the minimum of 80.0%"},
+			map[string]any{"path": "out/it.json", "status": fileFailed, "reason": "disk full"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary =\n%v\nwant\n%v", got, want)
+	}
+}
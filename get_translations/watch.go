@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// stagingMarker is the file in every tree the watcher writes, so that it only ever deletes its own trees
+const stagingMarker = ".get_translations_watch"
+
+type watchOptions struct {
+	Interval time.Duration
+	// the longest to wait between polls while loco is failing
+	MaxBackoff time.Duration
+	// process to send SIGHUP to after an update, directly or from a pid file
+	PID     int
+	PIDFile string
+	// file whose modification time is updated after an update
+	Sentinel string
+}
+
+// poWatcher keeps a PO tree up to date with loco
+type poWatcher struct {
+	apiKey       string
+	dir          string
+	opts         watchOptions
+	lastModified string
+	lastHash     [sha256.Size]byte
+}
+
+// watchPOExport polls loco for PO changes until interrupted. when the export changes, the tree in dir is replaced
+// and the configured process is notified. it stops when loco rejects the API key, which retrying won't fix.
+func watchPOExport(apiKey, dir string, opts watchOptions) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", opts.Interval)
+	}
+	if opts.MaxBackoff < opts.Interval {
+		opts.MaxBackoff = opts.Interval
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &poWatcher{apiKey: apiKey, dir: filepath.Clean(dir), opts: opts}
+	wait := opts.Interval
+	for {
+		changed, err := w.poll()
+		if errors.Is(err, errAuth) {
+			return err
+		}
+		if err != nil {
+			wait *= 2
+			if wait > opts.MaxBackoff {
+				wait = opts.MaxBackoff
+			}
+			slog.Error("error polling loco", slog.Any("err", err), slog.Duration("retryIn", wait))
+		} else {
+			wait = opts.Interval
+			if changed {
+				w.notify()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// poll downloads the export if it changed since the last poll and swaps it into place
+func (w *poWatcher) poll() (changed bool, err error) {
+	header := http.Header{}
+	if w.lastModified != "" {
+		header.Set("If-Modified-Since", w.lastModified)
+	}
+	resp, err := locoRequestWithHeaders(w.apiKey, locoPOExportURL, poExportQuery(), header)
+	if errors.Is(err, errNotModified) {
+		resp.Body.Close()
+		slog.Info("no changes")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	w.lastModified = resp.Header.Get("Last-Modified")
+	// not every export honors If-Modified-Since, so compare the content as well
+	hash := sha256.Sum256(body)
+	if hash == w.lastHash {
+		slog.Info("no changes")
+		return false, nil
+	}
+
+	// the run summary only covers the latest tree, rather than growing for as long as the watch runs
+	run.reset()
+	err = w.replaceTree(body)
+	if err != nil {
+		return false, err
+	}
+	w.lastHash = hash
+	slog.Info("translations updated", slog.String("dir", w.dir), slog.String("lastModified", w.lastModified))
+	return true, nil
+}
+
+// replaceTree writes the export next to dir and swaps it in. when dir is a symlink, the swap is atomic: the link is
+// replaced with one to the new tree, and the tree it pointed to is removed if it has the watcher's marker. a plain
+// directory can't be swapped atomically: the old tree is renamed to dir.old and the new one renamed in, so readers
+// can find dir missing for a moment, and the old tree is put back if the new one can't be renamed in. a dir.old that
+// the watcher didn't write is never deleted.
+func (w *poWatcher) replaceTree(zipData []byte) error {
+	parent := filepath.Dir(w.dir)
+	staging, err := os.MkdirTemp(parent, w.stagingPrefix())
+	if err != nil {
+		return err
+	}
+	// MkdirTemp makes it private to us, but the service reading the translations may run as someone else
+	err = os.Chmod(staging, 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(staging, stagingMarker), nil, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	err = writeLocoPO(staging, io.NopCloser(bytes.NewReader(zipData)), nil)
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	info, err := os.Lstat(w.dir)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		oldTarget, _ := os.Readlink(w.dir)
+		tmpLink := staging + ".link"
+		err = os.Symlink(filepath.Base(staging), tmpLink)
+		if err == nil {
+			err = os.Rename(tmpLink, w.dir)
+		}
+		if err != nil {
+			os.Remove(tmpLink)
+			os.RemoveAll(staging)
+			return err
+		}
+		if oldTarget != "" {
+			if !filepath.IsAbs(oldTarget) {
+				oldTarget = filepath.Join(parent, oldTarget)
+			}
+			if w.isStagingDir(oldTarget) {
+				os.RemoveAll(oldTarget)
+			}
+		}
+		return nil
+	}
+
+	old := w.dir + ".old"
+	hadOld := err == nil
+	if _, statErr := os.Lstat(old); statErr == nil {
+		if !w.isStagingDir(old) {
+			os.RemoveAll(staging)
+			return fmt.Errorf("%s is in the way and wasn't written by the watcher", old)
+		}
+		os.RemoveAll(old)
+	}
+	if hadOld {
+		err = os.Rename(w.dir, old)
+		if err != nil {
+			os.RemoveAll(staging)
+			return err
+		}
+	}
+	err = os.Rename(staging, w.dir)
+	if err != nil {
+		os.RemoveAll(staging)
+		if hadOld {
+			if restoreErr := os.Rename(old, w.dir); restoreErr != nil {
+				return fmt.Errorf("%w, and restoring the old tree failed: %v", err, restoreErr)
+			}
+		}
+		return err
+	}
+	if !hadOld {
+		return nil
+	}
+	return os.RemoveAll(old)
+}
+
+// stagingPrefix starts the names of the trees the watcher writes next to dir
+func (w *poWatcher) stagingPrefix() string {
+	return filepath.Base(w.dir) + "-"
+}
+
+// isStagingDir tells whether path is a tree the watcher wrote: a directory next to dir with the watcher's marker in
+// it. a symlink to a directory someone else made, even one named like the watcher's, never gets it deleted.
+func (w *poWatcher) isStagingDir(path string) bool {
+	path = filepath.Clean(path)
+	if filepath.Dir(path) != filepath.Dir(w.dir) {
+		return false
+	}
+	info, err := os.Lstat(filepath.Join(path, stagingMarker))
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (w *poWatcher) notify() {
+	if w.opts.Sentinel != "" {
+		now := time.Now()
+		err := os.Chtimes(w.opts.Sentinel, now, now)
+		if errors.Is(err, os.ErrNotExist) {
+			var f *os.File
+			f, err = os.Create(w.opts.Sentinel)
+			if err == nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			slog.Error("error touching sentinel", slog.String("file", w.opts.Sentinel), slog.Any("err", err))
+		}
+	}
+
+	pid := w.opts.PID
+	if w.opts.PIDFile != "" {
+		// read it every time, since the process may have restarted
+		data, err := os.ReadFile(w.opts.PIDFile)
+		if err != nil {
+			slog.Error("error reading pid file", slog.String("file", w.opts.PIDFile), slog.Any("err", err))
+			return
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			slog.Error("invalid pid file", slog.String("file", w.opts.PIDFile), slog.Any("err", err))
+			return
+		}
+	}
+	if pid <= 0 {
+		return
+	}
+	process, err := os.FindProcess(pid)
+	if err == nil {
+		err = process.Signal(syscall.SIGHUP)
+	}
+	if err != nil {
+		slog.Error("error signalling process", slog.Int("pid", pid), slog.Any("err", err))
+		return
+	}
+	slog.Info("sent SIGHUP", slog.Int("pid", pid))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPOZip(t *testing.T, msgstr string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("hourglass-po-archive/po/de_DE/LC_MESSAGES/messages.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Write([]byte("msgid \"hello\"\nmsgstr \"" + msgstr + "\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReplaceTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		symlink bool
+	}{
+		{name: "Directory"},
+		{name: "Symlink", symlink: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "translations")
+			if tt.symlink {
+				if err := os.Mkdir(filepath.Join(parent, "v0"), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink("v0", dir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := &poWatcher{dir: dir}
+			for _, msgstr := range []string{"Hallo", "Servus"} {
+				if err := w.replaceTree(testPOZip(t, msgstr)); err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(filepath.Join(dir, "de", "LC_MESSAGES", "messages.po"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Contains(data, []byte(msgstr)) {
+					t.Errorf("po file doesn't have %q:\n%s", msgstr, data)
+				}
+			}
+
+			info, err := os.Lstat(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink != tt.symlink {
+				t.Errorf("symlink = %v, want %v", isLink, tt.symlink)
+			}
+			// only the current tree should be left behind, and with a symlink the link and the tree it pointed to at
+			// first, which the watcher didn't write
+			entries, _ := os.ReadDir(parent)
+			expected := 1
+			if tt.symlink {
+				expected = 3
+				if _, err := os.Stat(filepath.Join(parent, "v0")); err != nil {
+					t.Errorf("the original tree was removed: %v", err)
+				}
+			}
+			if len(entries) != expected {
+				names := make([]string, len(entries))
+				for i, e := range entries {
+					names[i] = e.Name()
+				}
+				t.Errorf("left behind: %v", names)
+			}
+		})
+	}
+}
+
+func TestReplaceTreeKeepsOtherTrees(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "translations")
+	// a tree the user made, named like the watcher's
+	userTree := filepath.Join(parent, "translations-v2")
+	if err := os.Mkdir(userTree, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("translations-v2", dir); err != nil {
+		t.Fatal(err)
+	}
+	w := &poWatcher{dir: dir}
+	if err := w.replaceTree(testPOZip(t, "Hallo")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(userTree); err != nil {
+		t.Errorf("the user's tree was removed: %v", err)
+	}
+
+	// a dir.old the watcher didn't write is in the way
+	plain := filepath.Join(parent, "plain")
+	for _, d := range []string{plain, plain + ".old"} {
+		if err := os.Mkdir(d, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w = &poWatcher{dir: plain}
+	if err := w.replaceTree(testPOZip(t, "Hallo")); err == nil {
+		t.Error("replaceTree() replaced the tree with someone else's dir.old in the way")
+	}
+	if _, err := os.Stat(plain + ".old"); err != nil {
+		t.Errorf("the existing dir.old was removed: %v", err)
+	}
+}
+
+func TestIsStagingDir(t *testing.T) {
+	parent := t.TempDir()
+	w := &poWatcher{dir: filepath.Join(parent, "translations")}
+	marked := filepath.Join(parent, "translations-123456")
+	unmarked := filepath.Join(parent, "translations-v2")
+	elsewhere := filepath.Join(t.TempDir(), "translations-123456")
+	for _, d := range []string{marked, unmarked, elsewhere} {
+		if err := os.Mkdir(d, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, d := range []string{marked, elsewhere} {
+		if err := os.WriteFile(filepath.Join(d, stagingMarker), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: marked, want: true},
+		{path: marked + "/", want: true},
+		{path: unmarked, want: false},
+		{path: filepath.Join(parent, "missing"), want: false},
+		{path: elsewhere, want: false},
+	}
+	for _, tt := range tests {
+		if got := w.isStagingDir(tt.path); got != tt.want {
+			t.Errorf("isStagingDir(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
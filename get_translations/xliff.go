@@ -0,0 +1,511 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	xliffVersion12 = "1.2"
+	xliffVersion20 = "2.0"
+	xliffNS12      = "urn:oasis:names:tc:xliff:document:1.2"
+	xliffNS20      = "urn:oasis:names:tc:xliff:document:2.0"
+	xliffExt       = ".xlf"
+	// the context-type loco's context is exported as in 1.2 files
+	xliffLocoContext = "x-loco-context"
+
+	xliffState12New         = "new"
+	xliffState12Translated  = "translated"
+	xliffState12NeedsReview = "needs-review-translation"
+	xliffState20Initial     = "initial"
+	xliffState20Translated  = "translated"
+	xliffSubStateFlagged    = "loco:flagged"
+)
+
+type xliffExportOptions struct {
+	// 1.2 or 2.0
+	Version string
+	// only export assets with this tag
+	Tag string
+	// locales to export. every locale but the source when empty
+	Locales []string
+}
+
+// xliffUnit is a translation unit in either version
+type xliffUnit struct {
+	ID      string
+	Source  string
+	Target  string
+	State   string
+	Flagged bool
+	Notes   string
+	Context string
+}
+
+type xliff12 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Version string        `xml:"version,attr"`
+	NS      string        `xml:"xmlns,attr,omitempty"`
+	Files   []xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original       string        `xml:"original,attr"`
+	SourceLanguage string        `xml:"source-language,attr"`
+	TargetLanguage string        `xml:"target-language,attr"`
+	Datatype       string        `xml:"datatype,attr"`
+	Units          []xliff12Unit `xml:"body>trans-unit"`
+}
+
+type xliff12Unit struct {
+	ID           string               `xml:"id,attr"`
+	ResName      string               `xml:"resname,attr,omitempty"`
+	Source       string               `xml:"source"`
+	Target       *xliff12Target       `xml:"target,omitempty"`
+	Notes        []string             `xml:"note,omitempty"`
+	ContextGroup *xliff12ContextGroup `xml:"context-group,omitempty"`
+}
+
+type xliff12Target struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+type xliff12ContextGroup struct {
+	Purpose  string           `xml:"purpose,attr"`
+	Contexts []xliff12Context `xml:"context"`
+}
+
+type xliff12Context struct {
+	Type string `xml:"context-type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type xliff20 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Version string        `xml:"version,attr"`
+	NS      string        `xml:"xmlns,attr,omitempty"`
+	SrcLang string        `xml:"srcLang,attr"`
+	TrgLang string        `xml:"trgLang,attr"`
+	Files   []xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	ID    string        `xml:"id,attr"`
+	Units []xliff20Unit `xml:"unit"`
+}
+
+// xliff20Unit ids have to be NMTOKENs, which asset IDs often aren't, so the asset ID is the unit's name
+type xliff20Unit struct {
+	ID      string         `xml:"id,attr"`
+	Name    string         `xml:"name,attr"`
+	Notes   []xliff20Note  `xml:"notes>note,omitempty"`
+	Segment xliff20Segment `xml:"segment"`
+}
+
+type xliff20Note struct {
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+type xliff20Segment struct {
+	State    string `xml:"state,attr,omitempty"`
+	SubState string `xml:"subState,attr,omitempty"`
+	Source   string `xml:"source"`
+	Target   string `xml:"target,omitempty"`
+}
+
+// exportXLIFF writes an XLIFF file per locale to dir for a vendor to translate. the singular form of each asset is
+// exported; plural forms aren't.
+func exportXLIFF(apiKey, dir string, opts xliffExportOptions) error {
+	if opts.Version != xliffVersion12 && opts.Version != xliffVersion20 {
+		return fmt.Errorf("unknown XLIFF version %s: use %s or %s", opts.Version, xliffVersion12, xliffVersion20)
+	}
+	if !isValidDir(dir) {
+		return fmt.Errorf("invalid directory: %s", dir)
+	}
+
+	project, err := getLocoProject(apiKey)
+	if err != nil {
+		return err
+	}
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return fmt.Errorf("no source locale in loco")
+	}
+	assets, err := getAssets(apiKey, opts.Tag)
+	if err != nil {
+		return err
+	}
+	translations, err := fetchAllTranslations(apiKey, assets)
+	if err != nil {
+		return err
+	}
+
+	for _, loc := range allLocales {
+		if loc.Source || (len(opts.Locales) > 0 && !containsLocale(opts.Locales, loc.Code)) {
+			continue
+		}
+		units := xliffUnits(assets, translations, source.Code, loc.Code)
+		path := filepath.Join(dir, loc.Code+xliffExt)
+		err = writeXLIFFFile(path, opts.Version, project.Slug(), source.Code, loc.Code, units)
+		if err != nil {
+			return err
+		}
+		slog.Info("wrote XLIFF", slog.String("file", path), slog.Int("units", len(units)))
+	}
+	return nil
+}
+
+func containsLocale(codes []string, code string) bool {
+	for _, c := range codes {
+		if completionKey(c) == completionKey(code) {
+			return true
+		}
+	}
+	return false
+}
+
+func xliffUnits(assets []LocoAsset, translations map[string][]LocoTranslation, sourceCode, targetCode string) []xliffUnit {
+	units := make([]xliffUnit, 0, len(assets))
+	for _, asset := range assets {
+		unit := xliffUnit{ID: asset.ID, Notes: asset.Notes, Context: asset.Context}
+		for _, translation := range translations[asset.ID] {
+			switch translation.Locale.Code {
+			case sourceCode:
+				unit.Source = translation.Translation
+			case targetCode:
+				if translation.Translated {
+					unit.Target = translation.Translation
+				}
+				unit.Flagged = translation.Flagged
+			}
+		}
+		if unit.Source == "" {
+			// nothing to translate from
+			continue
+		}
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].ID < units[j].ID })
+	return units
+}
+
+func writeXLIFFFile(path, version, project, sourceCode, targetCode string, units []xliffUnit) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return run.record(path, err)
+	}
+	defer f.Close()
+	return run.record(path, encodeXLIFF(f, version, project, sourceCode, targetCode, units))
+}
+
+// encodeXLIFF writes a file of units named after the loco project
+func encodeXLIFF(out io.Writer, version, project, sourceCode, targetCode string, units []xliffUnit) error {
+	var doc any
+	if version == xliffVersion12 {
+		file := xliff12File{Original: project, SourceLanguage: sourceCode, TargetLanguage: targetCode,
+			Datatype: "plaintext"}
+		for _, unit := range units {
+			u := xliff12Unit{ID: unit.ID, ResName: unit.ID, Source: unit.Source}
+			state := xliffState12New
+			switch {
+			case unit.Flagged:
+				state = xliffState12NeedsReview
+			case unit.Target != "":
+				state = xliffState12Translated
+			}
+			u.Target = &xliff12Target{State: state, Text: unit.Target}
+			if unit.Notes != "" {
+				u.Notes = []string{unit.Notes}
+			}
+			if unit.Context != "" {
+				u.ContextGroup = &xliff12ContextGroup{Purpose: "information",
+					Contexts: []xliff12Context{{Type: xliffLocoContext, Text: unit.Context}}}
+			}
+			file.Units = append(file.Units, u)
+		}
+		doc = xliff12{Version: xliffVersion12, NS: xliffNS12, Files: []xliff12File{file}}
+	} else {
+		file := xliff20File{ID: project}
+		for i, unit := range units {
+			u := xliff20Unit{ID: fmt.Sprintf("u%d", i+1), Name: unit.ID,
+				Segment: xliff20Segment{State: xliffState20Initial, Source: unit.Source, Target: unit.Target}}
+			if unit.Target != "" {
+				u.Segment.State = xliffState20Translated
+			}
+			if unit.Flagged {
+				u.Segment.SubState = xliffSubStateFlagged
+			}
+			if unit.Context != "" {
+				u.Notes = append(u.Notes, xliff20Note{Category: "context", Text: unit.Context})
+			}
+			if unit.Notes != "" {
+				u.Notes = append(u.Notes, xliff20Note{Text: unit.Notes})
+			}
+			file.Units = append(file.Units, u)
+		}
+		doc = xliff20{Version: xliffVersion20, NS: xliffNS20, SrcLang: sourceCode, TrgLang: targetCode,
+			Files: []xliff20File{file}}
+	}
+
+	_, err := io.WriteString(out, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, "\n")
+	return err
+}
+
+// decodeXLIFF reads a 1.2 or 2.0 file and returns its target locale and units
+func decodeXLIFF(data []byte) (targetCode string, units []xliffUnit, err error) {
+	var header struct {
+		Version string `xml:"version,attr"`
+	}
+	err = xml.Unmarshal(data, &header)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch header.Version {
+	case xliffVersion12:
+		var doc xliff12
+		err = xml.Unmarshal(data, &doc)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, file := range doc.Files {
+			targetCode = file.TargetLanguage
+			for _, u := range file.Units {
+				unit := xliffUnit{ID: u.ID, Source: u.Source}
+				if u.ResName != "" {
+					unit.ID = u.ResName
+				}
+				if u.Target != nil {
+					unit.Target, unit.State = u.Target.Text, u.Target.State
+				}
+				units = append(units, unit)
+			}
+		}
+	case xliffVersion20:
+		var doc xliff20
+		err = xml.Unmarshal(data, &doc)
+		if err != nil {
+			return "", nil, err
+		}
+		targetCode = doc.TrgLang
+		for _, file := range doc.Files {
+			for _, u := range file.Units {
+				unit := xliffUnit{ID: u.Name, Source: u.Segment.Source, Target: u.Segment.Target, State: u.Segment.State}
+				if unit.ID == "" {
+					unit.ID = u.ID
+				}
+				units = append(units, unit)
+			}
+		}
+	default:
+		return "", nil, fmt.Errorf("unsupported XLIFF version %q", header.Version)
+	}
+	if targetCode == "" {
+		return "", nil, fmt.Errorf("no target language")
+	}
+	return targetCode, units, nil
+}
+
+// placeholderMismatch describes how the placeholders in target differ from those in source, or returns "" when they
+// are the same. the order doesn't matter, since translations often need to move them.
+func placeholderMismatch(source, target string) string {
+	count := make(map[string]int)
+	for _, p := range placeholderRegex.FindAllString(source, -1) {
+		count[p]++
+	}
+	for _, p := range placeholderRegex.FindAllString(target, -1) {
+		count[p]--
+	}
+	missing := make([]string, 0)
+	extra := make([]string, 0)
+	for p, n := range count {
+		for ; n > 0; n-- {
+			missing = append(missing, p)
+		}
+		for ; n < 0; n++ {
+			extra = append(extra, p)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	problems := make([]string, 0, 2)
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, " "))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, "unexpected "+strings.Join(extra, " "))
+	}
+	return strings.Join(problems, ", ")
+}
+
+type xliffImportFile struct {
+	path       string
+	targetCode string
+	units      []xliffUnit
+}
+
+// importXLIFF posts the translated units in the files to loco. units whose placeholders don't match their source in
+// loco, or that loco doesn't have, are skipped; the source in the file isn't trusted, since whoever translated it
+// could have changed it. the sources are read with apiKey and the translations posted with writeAPIKey; with dryRun
+// nothing is posted.
+func importXLIFF(apiKey, writeAPIKey string, paths []string, dryRun bool) error {
+	if writeAPIKey == "" && !dryRun {
+		return fmt.Errorf("%w: importing needs a loco API key that allows writing in %s", errAuth, writeAPIKeyVar)
+	}
+	files := make([]xliffImportFile, 0, len(paths))
+	ids := make(map[string]bool)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		targetCode, units, err := decodeXLIFF(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		translated := make([]xliffUnit, 0, len(units))
+		for _, unit := range units {
+			if unit.Target == "" || unit.State == xliffState12New || unit.State == xliffState20Initial {
+				continue
+			}
+			translated = append(translated, unit)
+			ids[unit.ID] = true
+		}
+		files = append(files, xliffImportFile{path: path, targetCode: targetCode, units: translated})
+	}
+	sources, err := locoSourceTexts(apiKey, ids)
+	if err != nil {
+		return err
+	}
+
+	invalid, failed, posted := 0, 0, 0
+	for _, file := range files {
+		path, targetCode := file.path, file.targetCode
+		for _, unit := range file.units {
+			if problem := importProblem(sources, unit.ID, unit.Target); problem != "" {
+				slog.Warn("invalid translation", slog.String("file", path),
+					slog.String("id", unit.ID), slog.String("problem", problem))
+				invalid++
+				continue
+			}
+			if dryRun {
+				posted++
+				continue
+			}
+			transURL := fmt.Sprintf(locoPostTranslationURL, url.PathEscape(unit.ID), targetCode)
+			resp, postErr := locoWrite(writeAPIKey, transURL, http.MethodPost, []byte(unit.Target))
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if postErr != nil {
+				slog.Error("failed to write translation", slog.String("id", unit.ID),
+					slog.String("locale", targetCode), slog.Any("err", postErr))
+				failed++
+				continue
+			}
+			posted++
+		}
+	}
+
+	verb := "imported"
+	if dryRun {
+		verb = "would import"
+	}
+	slog.Info(verb, slog.Int("translations", posted), slog.Int("invalid", invalid), slog.Int("failed", failed))
+	return importResult(invalid, failed)
+}
+
+// importProblem is why a translation for the asset id can't be imported, checked against the asset's source text in
+// loco, or empty when it can be
+func importProblem(sources map[string]string, id, text string) string {
+	source, ok := sources[id]
+	if !ok {
+		return "no such asset in loco"
+	}
+	if problem := placeholderMismatch(source, text); problem != "" {
+		return "placeholders don't match the source: " + problem
+	}
+	return ""
+}
+
+// locoSourceTexts gets the source locale's text of the assets in ids that loco has, by asset ID
+func locoSourceTexts(apiKey string, ids map[string]bool) (map[string]string, error) {
+	if len(ids) == 0 {
+		return map[string]string{}, nil
+	}
+	allLocales, err := getLocoLocales(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := sourceLocale(allLocales)
+	if !ok {
+		return nil, fmt.Errorf("no source locale in loco")
+	}
+	assets, err := getAssets(apiKey, "")
+	if err != nil {
+		return nil, err
+	}
+	wanted := make([]LocoAsset, 0, len(ids))
+	for _, asset := range assets {
+		if ids[asset.ID] {
+			wanted = append(wanted, asset)
+		}
+	}
+	translations, err := fetchAllTranslations(apiKey, wanted)
+	if err != nil {
+		return nil, err
+	}
+	return sourceTexts(translations, source.Code), nil
+}
+
+// sourceTexts picks the source locale's text out of each asset's translations
+func sourceTexts(translations map[string][]LocoTranslation, sourceCode string) map[string]string {
+	texts := make(map[string]string, len(translations))
+	for id, assetTranslations := range translations {
+		texts[id] = ""
+		for _, translation := range assetTranslations {
+			if translation.Locale.Code == sourceCode {
+				texts[id] = translation.Translation
+				break
+			}
+		}
+	}
+	return texts
+}
+
+// importResult is the error for an import that skipped invalid translations or failed to post some. placeholder
+// problems are a validation failure, posting failures a partial success.
+func importResult(invalid, failed int) error {
+	if invalid > 0 {
+		return fmt.Errorf("%w: %d invalid translations and %d failures", errValidation, invalid,
+			failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d translations failed to post", errPartial, failed)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestXLIFFRoundTrip(t *testing.T) {
+	units := []xliffUnit{
+		{ID: "import.drop-here %(filename)s", Source: "Drop %(filename)s here", Notes: "upload page",
+			Context: "button"},
+		{ID: "save", Source: "Save & close", Target: "Speichern & schließen"},
+		{ID: "cancel", Source: "Cancel", Target: "Abbrechen", Flagged: true},
+	}
+	tests := []struct {
+		version    string
+		wantStates []string
+	}{
+		{version: xliffVersion12,
+			wantStates: []string{xliffState12New, xliffState12Translated, xliffState12NeedsReview}},
+		{version: xliffVersion20,
+			wantStates: []string{xliffState20Initial, xliffState20Translated, xliffState20Translated}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeXLIFF(&buf, tt.version, "hourglass", "en-US", "de-DE", units); err != nil {
+				t.Fatal(err)
+			}
+			target, decoded, err := decodeXLIFF(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target != "de-DE" {
+				t.Errorf("target = %s, want de-DE", target)
+			}
+			if len(decoded) != len(units) {
+				t.Fatalf("got %d units, want %d:\n%s", len(decoded), len(units), buf.String())
+			}
+			for i, unit := range decoded {
+				want := xliffUnit{ID: units[i].ID, Source: units[i].Source, Target: units[i].Target,
+					State: tt.wantStates[i]}
+				if !reflect.DeepEqual(unit, want) {
+					t.Errorf("unit %d = %+v, want %+v", i, unit, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceholderMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target string
+		want   string
+	}{
+		{name: "Same", source: "Drop %(filename)s here", target: "%(filename)s hier ablegen"},
+		{name: "Reordered", source: "%1$s of %2$s", target: "%2$s von %1$s"},
+		{name: "Missing", source: "Hi {{name}}, %(count)d new", target: "Hallo {{name}}",
+			want: "missing %(count)d"},
+		{name: "Changed", source: "Hi %(name)s", target: "Hallo %(nom)s",
+			want: "missing %(name)s, unexpected %(nom)s"},
+		{name: "Duplicated", source: "%@", target: "%@ %@", want: "unexpected %@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := placeholderMismatch(tt.source, tt.target); got != tt.want {
+				t.Errorf("placeholderMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportProblem(t *testing.T) {
+	translation := func(locale, text string) LocoTranslation {
+		return LocoTranslation{LocoTranslationBase: LocoTranslationBase{Translation: text},
+			Locale: LocoLocale{Code: locale}}
+	}
+	sources := sourceTexts(map[string][]LocoTranslation{
+		"upload.drop": {translation("de-DE", "%(filename)s hier ablegen"), translation("en-US", "Drop %(filename)s here")},
+		"greeting":    {translation("en-US", "Hello")},
+	}, "en-US")
+
+	tests := []struct {
+		name string
+		id   string
+		text string
+		want string
+	}{
+		{name: "Valid", id: "upload.drop", text: "Déposez %(filename)s ici"},
+		// the file's source could say %(name)s too; loco's doesn't
+		{name: "EditedSource", id: "greeting", text: "Bonjour %(name)s",
+			want: "placeholders don't match the source: unexpected %(name)s"},
+		{name: "Missing", id: "upload.drop", text: "Déposez ici",
+			want: "placeholders don't match the source: missing %(filename)s"},
+		{name: "UnknownAsset", id: "nope", text: "Bonjour", want: "no such asset in loco"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := importProblem(sources, tt.id, tt.text); got != tt.want {
+				t.Errorf("importProblem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
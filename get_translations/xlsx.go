@@ -0,0 +1,216 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// just enough of the office open XML spreadsheet format for one sheet of strings
+
+const (
+	xlsxSheetPath         = "xl/worksheets/sheet1.xml"
+	xlsxSharedStringsPath = "xl/sharedStrings.xml"
+	xlsxMainNS            = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+)
+
+var xlsxParts = []struct {
+	path    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="translations" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+type xlsxWorksheet struct {
+	XMLName xml.Name  `xml:"worksheet"`
+	NS      string    `xml:"xmlns,attr,omitempty"`
+	Rows    []xlsxRow `xml:"sheetData>row"`
+}
+
+type xlsxRow struct {
+	Cells []xlsxCell `xml:"c"`
+}
+
+type xlsxCell struct {
+	Ref    string      `xml:"r,attr,omitempty"`
+	Type   string      `xml:"t,attr,omitempty"`
+	Value  string      `xml:"v,omitempty"`
+	Inline *xlsxString `xml:"is,omitempty"`
+}
+
+// xlsxString is plain text or, from spreadsheets saved by excel, runs of rich text
+type xlsxString struct {
+	Text *xlsxText `xml:"t,omitempty"`
+	Runs []xlsxRun `xml:"r,omitempty"`
+}
+
+type xlsxRun struct {
+	Text xlsxText `xml:"t"`
+}
+
+func (s xlsxString) String() string {
+	var sb strings.Builder
+	if s.Text != nil {
+		sb.WriteString(s.Text.Text)
+	}
+	for _, run := range s.Runs {
+		sb.WriteString(run.Text.Text)
+	}
+	return sb.String()
+}
+
+type xlsxText struct {
+	Space string `xml:"http://www.w3.org/XML/1998/namespace space,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxString `xml:"si"`
+}
+
+// writeXLSX writes the rows as inline strings, which every spreadsheet app reads without a shared string table
+func writeXLSX(out io.Writer, rows [][]string) error {
+	sheet := xlsxWorksheet{NS: xlsxMainNS}
+	for _, row := range rows {
+		var r xlsxRow
+		for _, text := range row {
+			r.Cells = append(r.Cells, xlsxCell{Type: "inlineStr",
+				Inline: &xlsxString{Text: &xlsxText{Space: "preserve", Text: text}}})
+		}
+		sheet.Rows = append(sheet.Rows, r)
+	}
+	sheetXML, err := xml.Marshal(sheet)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(out)
+	for _, part := range xlsxParts {
+		w, err := zw.Create(part.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, part.content)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := zw.Create(xlsxSheetPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(sheetXML)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readXLSX reads the first sheet of a workbook as strings
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	var sheet xlsxWorksheet
+	var shared xlsxSharedStrings
+	foundSheet := false
+	for _, f := range zr.File {
+		var target any
+		switch f.Name {
+		case xlsxSheetPath:
+			target, foundSheet = &sheet, true
+		case xlsxSharedStringsPath:
+			target = &shared
+		default:
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = xml.NewDecoder(rc).Decode(target)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	if !foundSheet {
+		return nil, fmt.Errorf("no %s in the workbook", xlsxSheetPath)
+	}
+
+	sharedStrings := make([]string, len(shared.Items))
+	for i, item := range shared.Items {
+		sharedStrings[i] = item.String()
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, r := range sheet.Rows {
+		row := make([]string, 0, len(r.Cells))
+		for _, cell := range r.Cells {
+			// empty cells are usually left out, so the reference says where a cell goes
+			if col, ok := xlsxColumn(cell.Ref); ok {
+				for len(row) < col {
+					row = append(row, "")
+				}
+			}
+			text := cell.Value
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(cell.Value)
+				if err != nil || i < 0 || i >= len(sharedStrings) {
+					return nil, fmt.Errorf("cell %s: bad shared string %q", cell.Ref, cell.Value)
+				}
+				text = sharedStrings[i]
+			case "inlineStr":
+				text = ""
+				if cell.Inline != nil {
+					text = cell.Inline.String()
+				}
+			}
+			row = append(row, text)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// xlsxColumn returns the zero based column of a cell reference like AB12
+func xlsxColumn(ref string) (int, bool) {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+		letters++
+	}
+	return col - 1, letters > 0
+}
@@ -0,0 +1,221 @@
+// Package translations loads the PO files written by get_translations and looks up strings in them, falling back
+// through related locales to the source locale when a string isn't translated.
+//
+// Message IDs are plain strings, so the constants in the generated asset_ids.go can be passed directly:
+//
+//	bundle, err := translations.LoadDir("/translations", language.AmericanEnglish)
+//	loc := bundle.Localizer(r.Header.Get("Accept-Language"))
+//	title := loc.Get(locale.ImportDropHere_Filename, map[string]any{"filename": name})
+package translations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	poSubdir   = "LC_MESSAGES"
+	poFilename = "messages.po"
+)
+
+// python style named placeholders, e.g. %(name)s or %(count)d, and escaped percent signs
+var namedPlaceholder = regexp.MustCompile(`%%|%\((\w+)\)([-+ #0]*\d*(?:\.\d+)?)([sdifeEgGxX])`)
+
+// Bundle holds the catalogs for every locale
+type Bundle struct {
+	source    language.Tag
+	supported []language.Tag // source first
+	catalogs  map[language.Tag]*Catalog
+	matcher   language.Matcher
+	fallbacks map[language.Tag][]language.Tag
+}
+
+// LoadDir loads the tree the po command writes: <dir>/<locale>/LC_MESSAGES/messages.po. source is the locale that
+// every lookup falls back to; it doesn't need a PO file, since the message IDs are used when it has none.
+func LoadDir(dir string, source language.Tag) (*Bundle, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := make(map[language.Tag]*Catalog, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tag, parseErr := language.Parse(entry.Name())
+		if parseErr != nil {
+			continue
+		}
+		poPath := filepath.Join(dir, entry.Name(), poSubdir, poFilename)
+		if _, statErr := os.Stat(poPath); statErr != nil {
+			continue
+		}
+		if _, ok := catalogs[tag]; ok {
+			// the po command writes some locales twice under differently cased names
+			continue
+		}
+		catalog, parseErr := ParsePOFile(poPath)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		catalogs[tag] = catalog
+	}
+	if len(catalogs) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", poFilename, dir)
+	}
+	return NewBundle(source, catalogs), nil
+}
+
+// NewBundle creates a bundle from catalogs that are already loaded
+func NewBundle(source language.Tag, catalogs map[language.Tag]*Catalog) *Bundle {
+	supported := []language.Tag{source}
+	for tag := range catalogs {
+		if tag != source {
+			supported = append(supported, tag)
+		}
+	}
+	// map order is random; keep the matcher's tie breaking stable
+	others := supported[1:]
+	sort.Slice(others, func(i, j int) bool { return others[i].String() < others[j].String() })
+
+	b := &Bundle{
+		source:    source,
+		supported: supported,
+		catalogs:  catalogs,
+		matcher:   language.NewMatcher(supported),
+		fallbacks: make(map[language.Tag][]language.Tag, len(supported)),
+	}
+	for _, tag := range supported {
+		if TagsMatch(tag, source, false) {
+			continue
+		}
+		b.fallbacks[tag] = Fallbacks(supported, tag, source)
+	}
+	return b
+}
+
+// Supported returns the locales in the bundle, starting with the source locale
+func (b *Bundle) Supported() []language.Tag {
+	return append([]language.Tag(nil), b.supported...)
+}
+
+// Match returns the supported locale for the preferences in Accept-Language headers. the source locale is used when
+// nothing matches.
+func (b *Bundle) Match(acceptLanguage ...string) language.Tag {
+	prefs := make([]language.Tag, 0)
+	for _, header := range acceptLanguage {
+		tags, _, err := language.ParseAcceptLanguage(header)
+		if err == nil {
+			prefs = append(prefs, tags...)
+		}
+	}
+	_, index, confidence := b.matcher.Match(prefs...)
+	if confidence == language.No {
+		return b.source
+	}
+	return b.supported[index]
+}
+
+// Localizer returns a localizer for the best match for the Accept-Language headers
+func (b *Bundle) Localizer(acceptLanguage ...string) *Localizer {
+	return b.LocalizerFor(b.Match(acceptLanguage...))
+}
+
+// LocalizerFor returns a localizer for a supported locale, or for its best match if it isn't supported
+func (b *Bundle) LocalizerFor(tag language.Tag) *Localizer {
+	if _, ok := b.catalogs[tag]; !ok && tag != b.source {
+		tag = Match(b.supported, tag)
+	}
+
+	// the fallback chain ends with the source locale, but its catalog may be under a different tag, e.g. en for en-US
+	tags := append([]language.Tag{tag}, b.fallbacks[tag]...)
+	for _, t := range b.supported {
+		if TagsMatch(t, b.source, false) {
+			tags = append(tags, t)
+		}
+	}
+
+	chain := make([]*Catalog, 0, len(tags))
+	for _, t := range tags {
+		if catalog, ok := b.catalogs[t]; ok && !slices.Contains(chain, catalog) {
+			chain = append(chain, catalog)
+		}
+	}
+	return &Localizer{tag: tag, chain: chain}
+}
+
+// Localizer looks up strings for one locale
+type Localizer struct {
+	tag   language.Tag
+	chain []*Catalog
+}
+
+// Tag is the locale the localizer is for
+func (l *Localizer) Tag() language.Tag {
+	return l.tag
+}
+
+// Get returns the translation of id with args substituted for its %(name)s placeholders. id itself is used when no
+// locale in the fallback chain has a translation.
+func (l *Localizer) Get(id string, args map[string]any) string {
+	return l.GetContext("", id, args)
+}
+
+// GetContext is Get for a message with a msgctxt
+func (l *Localizer) GetContext(context, id string, args map[string]any) string {
+	for _, catalog := range l.chain {
+		if translation, ok := catalog.Lookup(context, id); ok {
+			return Interpolate(translation, args)
+		}
+	}
+	return Interpolate(id, args)
+}
+
+// GetPlural returns the plural form of id for n. args may refer to n by its placeholder name; it isn't added
+// automatically. id or idPlural is used, as gettext does, when nothing in the fallback chain has a translation.
+func (l *Localizer) GetPlural(id, idPlural string, n int, args map[string]any) string {
+	for _, catalog := range l.chain {
+		if translation, ok := catalog.LookupPlural("", id, n); ok {
+			return Interpolate(translation, args)
+		}
+	}
+	if n == 1 {
+		return Interpolate(id, args)
+	}
+	return Interpolate(idPlural, args)
+}
+
+// Interpolate replaces python style %(name)s placeholders with the values in args and %% with %, the way python's %
+// operator does. placeholders without a value are left as they are.
+func Interpolate(s string, args map[string]any) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	return namedPlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+		if placeholder == "%%" {
+			return "%"
+		}
+		matches := namedPlaceholder.FindStringSubmatch(placeholder)
+		value, ok := args[matches[1]]
+		if !ok {
+			return placeholder
+		}
+		verb := matches[3]
+		switch verb {
+		case "s":
+			// python formats anything with %s
+			verb = "v"
+		case "i":
+			verb = "d"
+		}
+		return fmt.Sprintf("%"+matches[2]+verb, value)
+	})
+}
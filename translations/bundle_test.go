@@ -0,0 +1,124 @@
+package translations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func writeTestPO(t *testing.T, dir, locale, po string) {
+	t.Helper()
+	poDir := filepath.Join(dir, locale, poSubdir)
+	if err := os.MkdirAll(poDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(poDir, poFilename), []byte(po), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPO(t, dir, "en", `msgid "greeting %(name)s"
+msgstr "Hello %(name)s"
+
+msgid "farewell"
+msgstr "Goodbye"
+
+msgid "items"
+msgid_plural "items.plural"
+msgstr[0] "%(count)d item"
+msgstr[1] "%(count)d items"
+`)
+	writeTestPO(t, dir, "pt-BR", `msgid "greeting %(name)s"
+msgstr "Olá %(name)s"
+
+msgid "farewell"
+msgstr "Tchau"
+`)
+	writeTestPO(t, dir, "pt-PT", `msgid "greeting %(name)s"
+msgstr "Olá, %(name)s"
+
+msgid "farewell"
+msgstr ""
+`)
+	writeTestPO(t, dir, "de", `msgid "farewell"
+msgstr "Tschüss"
+`)
+
+	bundle, err := LoadDir(dir, language.AmericanEnglish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := map[string]any{"name": "Ana", "count": 3}
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		id             string
+		expectedTag    string
+		expected       string
+	}{
+		{name: "Exact", acceptLanguage: "pt-PT", id: "greeting %(name)s", expectedTag: "pt-PT",
+			expected: "Olá, Ana"},
+		{name: "Fallback to related locale", acceptLanguage: "pt-PT", id: "farewell", expectedTag: "pt-PT",
+			expected: "Tchau"},
+		{name: "Fallback to source", acceptLanguage: "de-CH, de;q=0.9", id: "greeting %(name)s", expectedTag: "de",
+			expected: "Hello Ana"},
+		{name: "Quality ordering", acceptLanguage: "fr;q=0.9, pt-BR", id: "farewell", expectedTag: "pt-BR",
+			expected: "Tchau"},
+		{name: "No match", acceptLanguage: "ja", id: "farewell", expectedTag: "en-US", expected: "Goodbye"},
+		{name: "Missing everywhere", acceptLanguage: "de", id: "missing %(name)s", expectedTag: "de",
+			expected: "missing Ana"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := bundle.Localizer(tt.acceptLanguage)
+			if loc.Tag().String() != tt.expectedTag {
+				t.Errorf("Localizer(%q).Tag() = %s, want %s", tt.acceptLanguage, loc.Tag(), tt.expectedTag)
+			}
+			if result := loc.Get(tt.id, args); result != tt.expected {
+				t.Errorf("Get(%q) = %q, want %q", tt.id, result, tt.expected)
+			}
+		})
+	}
+
+	de := bundle.LocalizerFor(language.German)
+	if result := de.GetPlural("items", "items.plural", 1, map[string]any{"count": 1}); result != "1 item" {
+		t.Errorf("GetPlural(1) = %q", result)
+	}
+	if result := de.GetPlural("items", "items.plural", 2, map[string]any{"count": 2}); result != "2 items" {
+		t.Errorf("GetPlural(2) = %q", result)
+	}
+	if result := de.GetPlural("things", "things.plural", 2, nil); result != "things.plural" {
+		t.Errorf("GetPlural for a missing message = %q", result)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		args     map[string]any
+		expected string
+	}{
+		{name: "String", input: "Hi %(name)s", args: map[string]any{"name": "Ana"}, expected: "Hi Ana"},
+		{name: "Int with s", input: "%(n)s left", args: map[string]any{"n": 3}, expected: "3 left"},
+		{name: "Int", input: "%(n)d left", args: map[string]any{"n": 3}, expected: "3 left"},
+		{name: "Int with i", input: "%(n)i left", args: map[string]any{"n": 3}, expected: "3 left"},
+		{name: "Precision", input: "%(pct).1f%%", args: map[string]any{"pct": 12.345}, expected: "12.3%"},
+		{name: "Missing arg", input: "Hi %(name)s", args: map[string]any{"other": 1}, expected: "Hi %(name)s"},
+		{name: "No args", input: "Hi %(name)s", expected: "Hi %(name)s"},
+		{name: "Percent without args", input: "100%% done", expected: "100% done"},
+		{name: "Lone percent", input: "50% off", expected: "50% off"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Interpolate(tt.input, tt.args); result != tt.expected {
+				t.Errorf("Interpolate(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package translations
+
+import (
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// Match returns the supported tag that is the best match for toMatch. the first supported tag is the default when
+// nothing matches.
+func Match(supported []language.Tag, toMatch language.Tag) language.Tag {
+	matcher := language.NewMatcher(supported)
+	_, index, _ := matcher.Match(toMatch)
+	return supported[index]
+}
+
+// Fallbacks returns the supported tags to try, in order, when a string is missing for toMatch. it ends with source.
+func Fallbacks(supported []language.Tag, toMatch, source language.Tag) []language.Tag {
+	matched := make([]language.Tag, 0)
+
+	otherSupported := make([]language.Tag, len(supported))
+	copy(otherSupported, supported)
+	otherSupported = slices.DeleteFunc(otherSupported, func(tag language.Tag) bool {
+		return TagsMatch(tag, toMatch, false)
+	})
+	match := Match(otherSupported, toMatch)
+	matched = append(matched, match)
+	if TagsMatch(match, source, false) {
+		return matched
+	}
+
+	nextSupported := make([]language.Tag, len(otherSupported))
+	copy(nextSupported, otherSupported)
+	nextSupported = slices.DeleteFunc(nextSupported, func(tag language.Tag) bool {
+		return TagsMatch(tag, match, false)
+	})
+	return append(matched, Fallbacks(nextSupported, toMatch, source)...)
+}
+
+// TagsMatch reports whether t1 and t2 have the same language, and the same region unless baseOnly is set
+func TagsMatch(t1, t2 language.Tag, baseOnly bool) bool {
+	if t1 == t2 {
+		return true
+	}
+	t1b, _ := t1.Base()
+	t2b, _ := t2.Base()
+	if t1b == t2b {
+		if baseOnly {
+			return true
+		}
+		t1r, _ := t1.Region()
+		t2r, _ := t2.Region()
+		return t1r == t2r
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package translations
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// pluralFunc returns the index of the msgstr plural form to use for n
+type pluralFunc func(n int) int
+
+// germanicPlural is the gettext default when there is no Plural-Forms header
+func germanicPlural(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+// parsePluralForms parses a Plural-Forms header such as "nplurals=2; plural=(n != 1);"
+func parsePluralForms(header string) (nplurals int, plural pluralFunc, err error) {
+	var expr string
+	for _, part := range strings.Split(header, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "nplurals":
+			nplurals, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid nplurals: %w", err)
+			}
+		case "plural":
+			expr = strings.TrimSpace(value)
+		}
+	}
+	if nplurals < 1 || expr == "" {
+		return 0, nil, errors.New("nplurals and plural are required")
+	}
+
+	p := &pluralParser{}
+	p.tokens, err = tokenizePlural(expr)
+	if err != nil {
+		return 0, nil, err
+	}
+	eval, err := p.ternary()
+	if err != nil {
+		return 0, nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return 0, nil, fmt.Errorf("unexpected %q in plural expression", p.tokens[p.pos])
+	}
+	return nplurals, pluralFunc(eval), nil
+}
+
+func tokenizePlural(expr string) ([]string, error) {
+	tokens := make([]string, 0)
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(expr) && unicode.IsDigit(rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, expr[start:i])
+		case i+1 < len(expr) && slices.Contains(pluralTwoCharOps, expr[i:i+2]):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case strings.ContainsRune("n?:()<>!%*/+-", c):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q in plural expression", c)
+		}
+	}
+	return tokens, nil
+}
+
+// pluralParser is a recursive descent parser for the C subset used in Plural-Forms. each level returns a function
+// that evaluates that part of the expression.
+type pluralParser struct {
+	tokens []string
+	pos    int
+}
+
+type evalFunc func(n int) int
+
+func (p *pluralParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *pluralParser) ternary() (evalFunc, error) {
+	cond, err := p.binary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.pos++
+	ifTrue, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ":" {
+		return nil, errors.New("missing : in plural expression")
+	}
+	p.pos++
+	ifFalse, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return ifTrue(n)
+		}
+		return ifFalse(n)
+	}, nil
+}
+
+var pluralTwoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+// binary operators from lowest to highest precedence
+var pluralPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", ">", "<=", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *pluralParser) binary(level int) (evalFunc, error) {
+	if level == len(pluralPrecedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if !slices.Contains(pluralPrecedence[level], op) {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = applyPluralOp(op, left, right)
+	}
+}
+
+func (p *pluralParser) unary() (evalFunc, error) {
+	switch tok := p.peek(); {
+	case tok == "!":
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int { return boolInt(operand(n) == 0) }, nil
+	case tok == "n":
+		p.pos++
+		return func(n int) int { return n }, nil
+	case tok == "(":
+		p.pos++
+		inner, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing ) in plural expression")
+		}
+		p.pos++
+		return inner, nil
+	case tok != "" && unicode.IsDigit(rune(tok[0])):
+		p.pos++
+		value, err := strconv.Atoi(tok)
+		if err != nil {
+			return nil, err
+		}
+		return func(int) int { return value }, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q in plural expression", tok)
+	}
+}
+
+func applyPluralOp(op string, left, right evalFunc) evalFunc {
+	switch op {
+	case "||":
+		return func(n int) int { return boolInt(left(n) != 0 || right(n) != 0) }
+	case "&&":
+		return func(n int) int { return boolInt(left(n) != 0 && right(n) != 0) }
+	case "==":
+		return func(n int) int { return boolInt(left(n) == right(n)) }
+	case "!=":
+		return func(n int) int { return boolInt(left(n) != right(n)) }
+	case "<":
+		return func(n int) int { return boolInt(left(n) < right(n)) }
+	case ">":
+		return func(n int) int { return boolInt(left(n) > right(n)) }
+	case "<=":
+		return func(n int) int { return boolInt(left(n) <= right(n)) }
+	case ">=":
+		return func(n int) int { return boolInt(left(n) >= right(n)) }
+	case "+":
+		return func(n int) int { return left(n) + right(n) }
+	case "-":
+		return func(n int) int { return left(n) - right(n) }
+	case "*":
+		return func(n int) int { return left(n) * right(n) }
+	case "/":
+		return func(n int) int {
+			if r := right(n); r != 0 {
+				return left(n) / r
+			}
+			return 0
+		}
+	}
+	// %
+	return func(n int) int {
+		if r := right(n); r != 0 {
+			return left(n) % r
+		}
+		return 0
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,238 @@
+package translations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// contextSeparator joins msgctxt and msgid into a catalog key, the same way gettext does
+const contextSeparator = "\x04"
+
+// Message is a single entry in a PO file
+type Message struct {
+	Context  string
+	ID       string
+	IDPlural string
+	// Str has one element for singular messages and one per plural form otherwise
+	Str []string
+}
+
+// Catalog is the contents of a PO file
+type Catalog struct {
+	Header   map[string]string
+	Messages map[string]*Message
+	plural   pluralFunc
+	nplurals int
+}
+
+// ParsePOFile reads and parses a PO (or POT) file
+func ParsePOFile(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	catalog, err := ParsePO(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// ParsePO parses gettext PO data. the header entry is parsed into Header and its Plural-Forms is used for plural
+// lookups.
+func ParsePO(r io.Reader) (*Catalog, error) {
+	catalog := &Catalog{
+		Header:   make(map[string]string),
+		Messages: make(map[string]*Message),
+	}
+
+	var msg *Message
+	// the string that continuation lines are appended to
+	var current *string
+	finish := func() {
+		if msg != nil {
+			catalog.add(msg)
+		}
+		msg = nil
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			finish()
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// comments, references and flags; obsolete (#~) entries are dropped as well
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		if strings.HasPrefix(line, `"`) {
+			keyword, rest = "", line
+		}
+		value, err := unquotePO(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		switch {
+		case keyword == "":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: string without a keyword", lineNum)
+			}
+			*current += value
+		case keyword == "msgctxt":
+			finish()
+			msg = &Message{Context: value}
+			current = &msg.Context
+		case keyword == "msgid":
+			if msg == nil || msg.ID != "" || len(msg.Str) > 0 {
+				finish()
+				msg = &Message{}
+			}
+			msg.ID = value
+			current = &msg.ID
+		case keyword == "msgid_plural":
+			if msg == nil {
+				return nil, fmt.Errorf("line %d: msgid_plural without msgid", lineNum)
+			}
+			msg.IDPlural = value
+			current = &msg.IDPlural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			if msg == nil {
+				return nil, fmt.Errorf("line %d: msgstr without msgid", lineNum)
+			}
+			index := 0
+			if keyword != "msgstr" {
+				index, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid plural index: %s", lineNum, keyword)
+				}
+			}
+			for len(msg.Str) <= index {
+				msg.Str = append(msg.Str, "")
+			}
+			msg.Str[index] = value
+			current = &msg.Str[index]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %s", lineNum, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+
+	return catalog, catalog.parsePluralForms()
+}
+
+func (c *Catalog) add(msg *Message) {
+	if msg.ID == "" && msg.Context == "" {
+		// the header entry
+		if len(msg.Str) > 0 {
+			for _, line := range strings.Split(msg.Str[0], "\n") {
+				key, value, found := strings.Cut(line, ":")
+				if found {
+					c.Header[strings.TrimSpace(key)] = strings.TrimSpace(value)
+				}
+			}
+		}
+		return
+	}
+	c.Messages[messageKey(msg.Context, msg.ID)] = msg
+}
+
+func (c *Catalog) parsePluralForms() error {
+	c.plural = germanicPlural
+	c.nplurals = 2
+	pluralForms, ok := c.Header["Plural-Forms"]
+	if !ok {
+		return nil
+	}
+	nplurals, plural, err := parsePluralForms(pluralForms)
+	if err != nil {
+		return fmt.Errorf("Plural-Forms: %w", err)
+	}
+	c.nplurals, c.plural = nplurals, plural
+	return nil
+}
+
+// Lookup returns the translation of id, or false when it isn't in the catalog or isn't translated
+func (c *Catalog) Lookup(context, id string) (string, bool) {
+	msg, ok := c.Messages[messageKey(context, id)]
+	if !ok || len(msg.Str) == 0 || msg.Str[0] == "" {
+		return "", false
+	}
+	return msg.Str[0], true
+}
+
+// LookupPlural returns the plural form of id for n, or false when it isn't in the catalog or isn't translated
+func (c *Catalog) LookupPlural(context, id string, n int) (string, bool) {
+	msg, ok := c.Messages[messageKey(context, id)]
+	if !ok {
+		return "", false
+	}
+	index := c.plural(n)
+	if index < 0 || index >= len(msg.Str) || msg.Str[index] == "" {
+		return "", false
+	}
+	return msg.Str[index], true
+}
+
+func messageKey(context, id string) string {
+	if context == "" {
+		return id
+	}
+	return context + contextSeparator + id
+}
+
+// unquotePO removes the quotes from a PO string and resolves its C style escapes
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("not a quoted string: %s", s)
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			// \\, \" and anything else that doesn't need translating
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,146 @@
+package translations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPO = `# Translation file
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+#: app/views.py:12
+msgid "import.drop-here %(filename)s"
+msgstr "Upuść tutaj %(filename)s"
+
+msgctxt "menu"
+msgid "file.open"
+msgstr "Otwórz"
+
+msgid "report.count"
+msgid_plural "report.count.plural"
+msgstr[0] "%(count)d raport"
+msgstr[1] "%(count)d raporty"
+msgstr[2] "%(count)d raportów"
+
+msgid "multi.line"
+msgstr ""
+"pierwsza\n"
+"druga \"cytat\""
+
+msgid "untranslated"
+msgstr ""
+
+#~ msgid "obsolete"
+#~ msgstr "przestarzały"
+`
+
+func TestParsePO(t *testing.T) {
+	catalog, err := ParsePO(strings.NewReader(testPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if catalog.Header["Language"] != "pl" {
+		t.Errorf("Language header = %q", catalog.Header["Language"])
+	}
+	if len(catalog.Messages) != 5 {
+		t.Errorf("got %d messages, want 5", len(catalog.Messages))
+	}
+
+	tests := []struct {
+		name     string
+		context  string
+		id       string
+		expected string
+		found    bool
+	}{
+		{name: "Simple", id: "import.drop-here %(filename)s", expected: "Upuść tutaj %(filename)s", found: true},
+		{name: "Context", context: "menu", id: "file.open", expected: "Otwórz", found: true},
+		{name: "Context required", id: "file.open", found: false},
+		{name: "Multi line", id: "multi.line", expected: "pierwsza\ndruga \"cytat\"", found: true},
+		{name: "Untranslated", id: "untranslated", found: false},
+		{name: "Obsolete", id: "obsolete", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, found := catalog.Lookup(tt.context, tt.id)
+			if result != tt.expected || found != tt.found {
+				t.Errorf("Lookup(%q, %q) = %q, %v, want %q, %v", tt.context, tt.id, result, found, tt.expected,
+					tt.found)
+			}
+		})
+	}
+
+	plurals := map[int]string{1: "%(count)d raport", 3: "%(count)d raporty", 5: "%(count)d raportów",
+		22: "%(count)d raporty", 112: "%(count)d raportów"}
+	for n, expected := range plurals {
+		if result, _ := catalog.LookupPlural("", "report.count", n); result != expected {
+			t.Errorf("LookupPlural(%d) = %q, want %q", n, result, expected)
+		}
+	}
+}
+
+func TestParsePOErrors(t *testing.T) {
+	tests := map[string]string{
+		"Unknown keyword":   "msgid \"a\"\nmsgfoo \"b\"\n",
+		"Unquoted":          "msgid a\n",
+		"Orphan string":     "\"a\"\n",
+		"Bad plural forms":  "msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=(n !! 1);\\n\"\n",
+		"msgstr only":       "msgstr \"a\"\n",
+		"Bad plural index":  "msgid \"a\"\nmsgstr[x] \"b\"\n",
+		"Plural without id": "msgid_plural \"a\"\n",
+	}
+	for name, po := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePO(strings.NewReader(po)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParsePluralForms(t *testing.T) {
+	tests := []struct {
+		header   string
+		nplurals int
+		expected []int // for n = 0..5, 11, 21, 101
+	}{
+		{header: "nplurals=1; plural=0;", nplurals: 1, expected: []int{0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{header: "nplurals=2; plural=(n != 1);", nplurals: 2, expected: []int{1, 0, 1, 1, 1, 1, 1, 1, 1}},
+		{header: "nplurals=2; plural=(n > 1);", nplurals: 2, expected: []int{0, 0, 1, 1, 1, 1, 1, 1, 1}},
+		{
+			header:   "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+			nplurals: 3,
+			expected: []int{2, 0, 1, 1, 1, 2, 2, 0, 0},
+		},
+		{
+			header:   "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+			nplurals: 6,
+			expected: []int{0, 1, 2, 3, 3, 3, 4, 4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			nplurals, plural, err := parsePluralForms(tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if nplurals != tt.nplurals {
+				t.Errorf("nplurals = %d, want %d", nplurals, tt.nplurals)
+			}
+			result := make([]int, 0, len(tt.expected))
+			for _, n := range []int{0, 1, 2, 3, 4, 5, 11, 21, 101} {
+				result = append(result, plural(n))
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("plural forms = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}